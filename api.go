@@ -0,0 +1,427 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/praneeth-ayla/gator/internal/database"
+)
+
+// apiPrefix is where version 1 of the REST API is served. Breaking changes
+// get a new version next to it.
+const apiPrefix = "/api/v1"
+
+// Page sizes for listing endpoints.
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// openAPIDocument describes the REST API. It is served as it is, so keep it
+// in step with the routes in newAPI.
+//
+//go:embed api/openapi.json
+var openAPIDocument []byte
+
+// api serves the REST API over the same queries as the commands. Every
+// endpoint but the OpenAPI document needs an API token, sent as
+// "Authorization: Bearer <token>", and acts as the token's user.
+type api struct {
+	s *state
+}
+
+// apiHandler serves an endpoint for the user a request authenticated as.
+// A returned error becomes the JSON error body.
+type apiHandler func(w http.ResponseWriter, r *http.Request, user database.User) error
+
+// newAPI routes the REST API.
+func newAPI(s *state) http.Handler {
+	a := &api{s: s}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+apiPrefix+"/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPIDocument)
+	})
+	mux.Handle("GET "+apiPrefix+"/me", a.authed(a.handleMe))
+	mux.Handle("GET "+apiPrefix+"/users", a.authed(a.handleUsers))
+	mux.Handle("GET "+apiPrefix+"/feeds", a.authed(a.handleFeeds))
+	mux.Handle("GET "+apiPrefix+"/follows", a.authed(a.handleFollows))
+	mux.Handle("POST "+apiPrefix+"/follows", a.authed(a.handleFollow))
+	mux.Handle("DELETE "+apiPrefix+"/follows", a.authed(a.handleUnfollow))
+	mux.Handle("GET "+apiPrefix+"/posts", a.authed(a.handlePosts))
+	mux.Handle("GET "+apiPrefix+"/posts/{number}", a.authed(a.handlePost))
+	mux.Handle("PUT "+apiPrefix+"/posts/{number}/read", a.authed(a.handleSetRead(true)))
+	mux.Handle("DELETE "+apiPrefix+"/posts/{number}/read", a.authed(a.handleSetRead(false)))
+	mux.Handle("PUT "+apiPrefix+"/posts/{number}/star", a.authed(a.handleSetStarred(true)))
+	mux.Handle("DELETE "+apiPrefix+"/posts/{number}/star", a.authed(a.handleSetStarred(false)))
+	// Anything else gets a JSON error too, rather than the mux's plain text.
+	mux.HandleFunc(apiPrefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, newError(errNotFound, "no endpoint %s %s", r.Method, r.URL.Path))
+	})
+	return mux
+}
+
+// authed authenticates requests with their API token before running handler.
+func (a *api) authed(handler apiHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := a.authenticate(r)
+		if err == nil {
+			err = handler(w, r, user)
+		}
+		if err != nil {
+			writeAPIError(w, err)
+		}
+	})
+}
+
+// authenticate returns the user whose API token a request carries.
+func (a *api) authenticate(r *http.Request) (database.User, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return database.User{}, newError(errAuth, "send an API token as \"Authorization: Bearer <token>\", create one with \"gator token create\"")
+	}
+	user, _, err := tokenUser(r.Context(), a.s, token)
+	if errors.Is(err, errAuth) {
+		return user, newError(errAuth, "invalid API token")
+	}
+	return user, err
+}
+
+// handleMe describes the authenticated user.
+func (a *api) handleMe(w http.ResponseWriter, r *http.Request, user database.User) error {
+	view, err := newUserDetailView(r.Context(), a.s, user)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, view)
+}
+
+// handleUsers lists every user, marking the authenticated one as current.
+func (a *api) handleUsers(w http.ResponseWriter, r *http.Request, user database.User) error {
+	page, err := parsePage(r)
+	if err != nil {
+		return err
+	}
+	users, err := a.s.db.GetUsers(r.Context())
+	if err != nil {
+		return err
+	}
+	views := make([]userView, 0, len(users))
+	for _, u := range users {
+		views = append(views, userView{
+			Name:      u.Name,
+			Role:      u.Role,
+			Current:   u.ID == user.ID,
+			CreatedAt: u.CreatedAt,
+		})
+	}
+	return writeJSON(w, http.StatusOK, paginate(views, page))
+}
+
+// handleFeeds lists every registered feed.
+func (a *api) handleFeeds(w http.ResponseWriter, r *http.Request, user database.User) error {
+	page, err := parsePage(r)
+	if err != nil {
+		return err
+	}
+	views, err := feedViews(r.Context(), a.s)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, paginate(views, page))
+}
+
+// handleFollows lists the feeds the user follows, oldest follow first.
+func (a *api) handleFollows(w http.ResponseWriter, r *http.Request, user database.User) error {
+	page, err := parsePage(r)
+	if err != nil {
+		return err
+	}
+	views, err := followViews(r.Context(), a.s, user)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, paginate(views, page))
+}
+
+// followRequest is the body of POST /follows.
+type followRequest struct {
+	URL  string `json:"url"`
+	Name string `json:"name"`
+}
+
+// handleFollow follows a feed by URL, adding it first when nobody has yet,
+// and responds with the new follow.
+func (a *api) handleFollow(w http.ResponseWriter, r *http.Request, user database.User) error {
+	var req followRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		return err
+	}
+	if req.URL == "" {
+		return newError(errUsage, "url is required")
+	}
+	if _, _, err := followFeed(r.Context(), a.s, user, req.URL, req.Name); err != nil {
+		return err
+	}
+
+	views, err := followViews(r.Context(), a.s, user)
+	if err != nil {
+		return err
+	}
+	for _, view := range views {
+		if view.FeedURL == req.URL {
+			return writeJSON(w, http.StatusCreated, view)
+		}
+	}
+	return newError(errNotFound, "you don't follow %s", req.URL)
+}
+
+// handleUnfollow stops following the feed given by the url query parameter.
+func (a *api) handleUnfollow(w http.ResponseWriter, r *http.Request, user database.User) error {
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		return newError(errUsage, "the url query parameter is required")
+	}
+	deleted, err := a.s.db.DeleteFeedFollow(r.Context(), database.DeleteFeedFollowParams{
+		Url:  url,
+		Name: user.Name,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return newError(errNotFound, "you don't follow %s", url)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// handlePosts lists the newest posts from the user's follows, optionally
+// only from feeds carrying a tag or from one feed, leaving out the ones
+// filter rules hide as browse does.
+func (a *api) handlePosts(w http.ResponseWriter, r *http.Request, user database.User) error {
+	page, err := parsePage(r)
+	if err != nil {
+		return err
+	}
+	query := postQuery{
+		Tag:    r.URL.Query().Get("tag"),
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	}
+	if feedURL := r.URL.Query().Get("feed"); feedURL != "" {
+		feed, err := a.s.db.GetFeedByUrl(r.Context(), feedURL)
+		if err != nil {
+			return notFound(err, "no feed with url %s", feedURL)
+		}
+		query.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	views, err := listPosts(r.Context(), a.s, user, query)
+	if err != nil {
+		return err
+	}
+	body := pageView[postView]{Items: views, Limit: page.Limit, Offset: page.Offset}
+	if len(views) == page.Limit {
+		next := page.Offset + len(views)
+		body.NextOffset = &next
+	}
+	return writeJSON(w, http.StatusOK, body)
+}
+
+// handlePost shows one post by its number.
+func (a *api) handlePost(w http.ResponseWriter, r *http.Request, user database.User) error {
+	number, err := postNumber(r)
+	if err != nil {
+		return err
+	}
+	view, err := postByNumber(r.Context(), a.s, user, number)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, view)
+}
+
+// handleSetRead marks a post read (PUT) or unread (DELETE) and responds
+// with the post.
+func (a *api) handleSetRead(read bool) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request, user database.User) error {
+		return a.updatePost(w, r, user, func(postID uuid.UUID) error {
+			_, err := setPostRead(r.Context(), a.s, user.ID, postID, read)
+			return err
+		})
+	}
+}
+
+// handleSetStarred stars (PUT) or unstars (DELETE) a post and responds with
+// the post.
+func (a *api) handleSetStarred(starred bool) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request, user database.User) error {
+		return a.updatePost(w, r, user, func(postID uuid.UUID) error {
+			_, err := setPostStarred(r.Context(), a.s, user.ID, postID, starred)
+			return err
+		})
+	}
+}
+
+// updatePost applies update to the post a request names, then responds
+// with the post as it is now. Posts the user's rules hide aren't found.
+func (a *api) updatePost(w http.ResponseWriter, r *http.Request, user database.User, update func(uuid.UUID) error) error {
+	number, err := postNumber(r)
+	if err != nil {
+		return err
+	}
+	view, err := postByNumber(r.Context(), a.s, user, number)
+	if err != nil {
+		return err
+	}
+	id, err := uuid.Parse(view.ID)
+	if err != nil {
+		return err
+	}
+	if err := update(id); err != nil {
+		return err
+	}
+	view, err = postByNumber(r.Context(), a.s, user, number)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, view)
+}
+
+// postNumber parses the post number in a request path.
+func postNumber(r *http.Request) (int64, error) {
+	number, err := strconv.ParseInt(r.PathValue("number"), 10, 64)
+	if err != nil || number < 1 {
+		return 0, newError(errUsage, "post number must be a positive number, got %q", r.PathValue("number"))
+	}
+	return number, nil
+}
+
+// page is the part of a listing a request asks for with the limit and
+// offset query parameters.
+type page struct {
+	Limit  int
+	Offset int
+}
+
+// parsePage reads the limit and offset query parameters.
+func parsePage(r *http.Request) (page, error) {
+	p := page{Limit: defaultPageLimit}
+	query := r.URL.Query()
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return p, newError(errUsage, "limit must be a number from 1 to %d, got %q", maxPageLimit, value)
+		}
+		p.Limit = limit
+	}
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return p, newError(errUsage, "offset must be a number of at least 0, got %q", value)
+		}
+		p.Offset = offset
+	}
+	return p, nil
+}
+
+// pageView is the body of every listing endpoint. NextOffset is null on the
+// last page.
+type pageView[T any] struct {
+	Items      []T  `json:"items"`
+	Limit      int  `json:"limit"`
+	Offset     int  `json:"offset"`
+	NextOffset *int `json:"next_offset"`
+}
+
+// paginate cuts the requested page out of a complete listing.
+func paginate[T any](items []T, p page) pageView[T] {
+	view := pageView[T]{Items: []T{}, Limit: p.Limit, Offset: p.Offset}
+	if p.Offset >= len(items) {
+		return view
+	}
+	end := min(p.Offset+p.Limit, len(items))
+	view.Items = items[p.Offset:end]
+	if end < len(items) {
+		view.NextOffset = &end
+	}
+	return view
+}
+
+// decodeJSON reads a JSON request body into v, rejecting unknown fields.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return newError(errUsage, "invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+// writeJSON responds with v as JSON.
+func writeJSON(w http.ResponseWriter, status int, v any) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(v)
+}
+
+// httpErrors maps each error kind to the HTTP status and the code API error
+// bodies carry. Errors without a kind are internal errors.
+var httpErrors = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{errUsage, http.StatusBadRequest, "invalid_request"},
+	{errAuth, http.StatusUnauthorized, "unauthorized"},
+	{errNotLoggedIn, http.StatusUnauthorized, "unauthorized"},
+	{errPermission, http.StatusForbidden, "forbidden"},
+	{errNotFound, http.StatusNotFound, "not_found"},
+	{errAlreadyExists, http.StatusConflict, "already_exists"},
+	{errParse, http.StatusUnprocessableEntity, "invalid_feed"},
+	{errNetwork, http.StatusBadGateway, "feed_unreachable"},
+	{errTimeout, http.StatusGatewayTimeout, "timeout"},
+	{errInterrupted, http.StatusServiceUnavailable, "unavailable"},
+}
+
+// httpError returns the status, code and message to respond to err with.
+// Internal errors are logged rather than shown, like raw database errors in
+// main.
+func httpError(err error) (int, string, string) {
+	err = classify(err)
+	for _, e := range httpErrors {
+		if errors.Is(err, e.kind) {
+			return e.status, e.code, err.Error()
+		}
+	}
+	log.Printf("serve: %v", err)
+	return http.StatusInternalServerError, "internal", "internal server error"
+}
+
+// apiErrorView is the body of every error response.
+type apiErrorView struct {
+	Error apiErrorDetail `json:"error"`
+}
+
+type apiErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeAPIError responds with the status and JSON body for err.
+func writeAPIError(w http.ResponseWriter, err error) {
+	status, code, message := httpError(err)
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="gator"`)
+	}
+	writeJSON(w, status, apiErrorView{Error: apiErrorDetail{Code: code, Message: message}})
+}
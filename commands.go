@@ -1,28 +1,500 @@
 package main
 
-import "errors"
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
 
-// command represents a single command with its name and arguments.
+// command represents a single invocation of a command with its full name,
+// positional arguments and parsed flags.
 type command struct {
-	Name string
-	Args []string
+	Name  string
+	Args  []string
+	Flags *flag.FlagSet
+}
+
+// flagValue returns the parsed value of a flag, or nil if it isn't defined.
+func (c command) flagValue(name string) any {
+	if c.Flags == nil {
+		return nil
+	}
+	f := c.Flags.Lookup(name)
+	if f == nil {
+		return nil
+	}
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return f.Value.String()
+	}
+	return getter.Get()
+}
+
+// String returns the value of a string flag.
+func (c command) String(name string) string {
+	v, _ := c.flagValue(name).(string)
+	return v
+}
+
+// Int returns the value of an int flag.
+func (c command) Int(name string) int {
+	v, _ := c.flagValue(name).(int)
+	return v
+}
+
+// Bool returns the value of a bool flag.
+func (c command) Bool(name string) bool {
+	v, _ := c.flagValue(name).(bool)
+	return v
+}
+
+// Duration returns the value of a duration flag.
+func (c command) Duration(name string) time.Duration {
+	v, _ := c.flagValue(name).(time.Duration)
+	return v
+}
+
+// argSpec describes one positional argument of a command.
+type argSpec struct {
+	Name     string
+	Optional bool
+	// Variadic arguments accept one or more values (zero or more if Optional)
+	// and must come last.
+	Variadic bool
+	// Complete suggests values for shell completion.
+	Complete completer
+}
+
+// commandSpec declares a command: its documentation, arguments, flags,
+// handler and nested subcommands.
+type commandSpec struct {
+	Name        string
+	Description string
+	Args        []argSpec
+	// Flags defines the command's flags on a fresh FlagSet.
+	Flags func(fs *flag.FlagSet)
+	// FlagCompletions suggests values for flags, keyed by flag name.
+	FlagCompletions map[string]completer
+	// RawArgs passes every argument through to the handler without
+	// parsing flags.
+	RawArgs bool
+	// Handler runs the command. Commands with subcommands may leave it nil,
+	// in which case running them without a subcommand prints their help.
+	Handler     func(context.Context, *state, command) error
+	Subcommands []*commandSpec
+	// Hidden commands work but are left out of help listings.
+	Hidden bool
+	// Interactive commands handle Ctrl-C themselves and run without a
+	// timeout; the shell cancels each command it runs instead.
+	Interactive bool
+}
+
+// usage renders the argument synopsis, e.g. "<name> <url> [tag...]".
+func (spec *commandSpec) usage() string {
+	parts := make([]string, 0, len(spec.Args))
+	for _, arg := range spec.Args {
+		name := arg.Name
+		if arg.Variadic {
+			name += "..."
+		}
+		if arg.Optional {
+			parts = append(parts, "["+name+"]")
+		} else {
+			parts = append(parts, "<"+name+">")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// arity returns the minimum and maximum number of positional arguments;
+// a maximum of -1 means unlimited.
+func (spec *commandSpec) arity() (int, int) {
+	min, max := 0, 0
+	for _, arg := range spec.Args {
+		if !arg.Optional {
+			min++
+		}
+		if arg.Variadic {
+			max = -1
+		} else if max >= 0 {
+			max++
+		}
+	}
+	return min, max
+}
+
+// subcommand looks up a direct subcommand by name.
+func (spec *commandSpec) subcommand(name string) (*commandSpec, bool) {
+	for _, sub := range spec.Subcommands {
+		if sub.Name == name {
+			return sub, true
+		}
+	}
+	return nil, false
+}
+
+// newFlagSet builds a FlagSet holding the command's own flags.
+func (spec *commandSpec) newFlagSet(path string) *flag.FlagSet {
+	fs := flag.NewFlagSet(path, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if spec.Flags != nil {
+		spec.Flags(fs)
+	}
+	return fs
+}
+
+// usageError reports invalid input together with the command's usage line.
+func usageError(path string, spec *commandSpec, format string, args ...any) error {
+	synopsis := strings.TrimSpace("gator " + path + " " + spec.usage())
+	return fmt.Errorf("%w: %s\nusage: %s", errUsage, fmt.Sprintf(format, args...), synopsis)
 }
 
 // commands manages the registration and execution of application commands.
 type commands struct {
-	registeredCommands map[string]func(*state, command) error
+	registeredCommands map[string]*commandSpec
+	// globalFlags defines flags accepted before the command name as well as
+	// by every command.
+	globalFlags func(fs *flag.FlagSet)
+	// globalFlagCompletions suggests values for global flags.
+	globalFlagCompletions map[string]completer
+	out                   io.Writer
+}
+
+// newCommandSet creates an empty command registry that prints help to stdout.
+func newCommandSet() commands {
+	return commands{
+		registeredCommands: make(map[string]*commandSpec),
+		out:                os.Stdout,
+	}
+}
+
+// register adds a top-level command to the registry.
+func (c *commands) register(spec *commandSpec) {
+	c.registeredCommands[spec.Name] = spec
+}
+
+// flagSet builds the FlagSet for a command invocation: the command's own
+// flags plus the global ones.
+func (c *commands) flagSet(path string, spec *commandSpec) *flag.FlagSet {
+	fs := spec.newFlagSet(path)
+	if c.globalFlags != nil {
+		c.globalFlags(fs)
+	}
+	return fs
+}
+
+// splitGlobals parses the global flags given before the command name and
+// returns them with the remaining arguments.
+func (c *commands) splitGlobals(args []string) (*flag.FlagSet, []string, error) {
+	fs := flag.NewFlagSet("gator", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if c.globalFlags != nil {
+		c.globalFlags(fs)
+	}
+	if err := fs.Parse(args); err != nil {
+		return fs, nil, err
+	}
+	return fs, fs.Args(), nil
+}
+
+// commandName returns the name of the command a command line invokes,
+// skipping any leading global flags.
+func (c *commands) commandName(args []string) string {
+	_, rest, err := c.splitGlobals(args)
+	if err != nil || len(rest) == 0 {
+		return ""
+	}
+	return rest[0]
+}
+
+// findFlag returns the value of a flag anywhere on a command line. Settings
+// such as --config are needed before the command line is fully parsed.
+func findFlag(args []string, name string) string {
+	value := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		flagName, flagValue, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if flagName != name {
+			continue
+		}
+		if hasValue {
+			value = flagValue
+		} else if i+1 < len(args) {
+			value = args[i+1]
+			i++
+		}
+	}
+	return value
+}
+
+// run resolves a command line such as ["tags", "rename", "a", "b"] to its
+// command, parses flags, validates arguments and runs the handler. The
+// handler's context is cancelled by Ctrl-C or once --timeout passes.
+func (c *commands) run(ctx context.Context, s *state, args []string) error {
+	globals, args, err := c.splitGlobals(args)
+	if errors.Is(err, flag.ErrHelp) {
+		c.printOverview()
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if len(args) == 0 {
+		c.printOverview()
+		return nil
+	}
+
+	spec, ok := c.registeredCommands[args[0]]
+	if !ok {
+		return fmt.Errorf("%w: unknown command %q, run \"gator help\" for a list", errUsage, args[0])
+	}
+	path := spec.Name
+	args = args[1:]
+
+	// Descend into subcommands while the next argument names one.
+	for len(args) > 0 {
+		sub, ok := spec.subcommand(args[0])
+		if !ok {
+			break
+		}
+		spec = sub
+		path += " " + sub.Name
+		args = args[1:]
+	}
+
+	// The output setting and global flags given before the command become
+	// defaults that the command's own arguments can still override.
+	fs := c.flagSet(path, spec)
+	if s != nil && s.cfg != nil && s.cfg.Output != "" {
+		fs.Set("output", s.cfg.Output)
+	}
+	globals.Visit(func(f *flag.Flag) {
+		fs.Set(f.Name, f.Value.String())
+	})
+
+	positional := args
+	if !spec.RawArgs {
+		positional, err = parseInterspersed(fs, args)
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		c.printHelp(path, spec)
+		return nil
+	}
+	if err != nil {
+		return usageError(path, spec, "%v", err)
+	}
+
+	if spec.Handler == nil {
+		if len(positional) > 0 {
+			return usageError(path, spec, "unknown subcommand %q", positional[0])
+		}
+		c.printHelp(path, spec)
+		return nil
+	}
+
+	min, max := spec.arity()
+	if len(positional) < min || (max >= 0 && len(positional) > max) {
+		return usageError(path, spec, "expected %s, got %d", describeArity(min, max), len(positional))
+	}
+
+	cmd := command{Name: path, Args: positional, Flags: fs}
+	if spec.Interactive {
+		return spec.Handler(ctx, s, cmd)
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	timeout := cmd.Duration("timeout")
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err = spec.Handler(ctx, s, cmd)
+	if err != nil {
+		err = cancelled(ctx, err, timeout)
+	}
+	return err
 }
 
-// register adds a command handler to the map.
-func (c *commands) register(name string, f func(*state, command) error) {
-	c.registeredCommands[name] = f
+// cancelled replaces the error of a command whose context ended with one
+// saying why, since drivers and HTTP clients word it in their own ways.
+func cancelled(ctx context.Context, err error, timeout time.Duration) error {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return newError(errTimeout, "timed out after %s", timeout)
+	case ctx.Err() != nil:
+		return newError(errInterrupted, "interrupted")
+	}
+	return err
+}
+
+// describeArity turns an argument count range into words.
+func describeArity(min, max int) string {
+	plural := func(n int) string {
+		if n == 1 {
+			return "1 argument"
+		}
+		return fmt.Sprintf("%d arguments", n)
+	}
+	switch {
+	case max < 0:
+		return "at least " + plural(min)
+	case min == max:
+		return plural(min)
+	case min == 0:
+		return "at most " + plural(max)
+	default:
+		return fmt.Sprintf("%d to %d arguments", min, max)
+	}
 }
 
-// run executes a command by its name with the given state and arguments.
-func (c *commands) run(s *state, cmd command) error {
-	f, ok := c.registeredCommands[cmd.Name]
+// parseInterspersed parses flags that may appear before, between or after
+// positional arguments, and returns the positional arguments. A "--"
+// argument ends flag parsing.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		// fs.Parse consumes a "--" terminator itself, so check what it skipped.
+		consumed := len(args) - len(rest)
+		if consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// lookup finds a command by its path, e.g. ["tags", "rename"].
+func (c *commands) lookup(path []string) (*commandSpec, bool) {
+	if len(path) == 0 {
+		return nil, false
+	}
+	spec, ok := c.registeredCommands[path[0]]
+	for _, name := range path[1:] {
+		if !ok {
+			break
+		}
+		spec, ok = spec.subcommand(name)
+	}
+	return spec, ok
+}
+
+// sortedCommands returns the visible top-level commands in name order.
+func (c *commands) sortedCommands() []*commandSpec {
+	specs := make([]*commandSpec, 0, len(c.registeredCommands))
+	for _, spec := range c.registeredCommands {
+		if !spec.Hidden {
+			specs = append(specs, spec)
+		}
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs
+}
+
+// printOverview prints the list of top-level commands.
+func (c *commands) printOverview() {
+	fmt.Fprintln(c.out, "Usage: gator <command> [flags] [args...]")
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, "Commands:")
+	tw := tabwriter.NewWriter(c.out, 0, 0, 3, ' ', 0)
+	for _, spec := range c.sortedCommands() {
+		fmt.Fprintf(tw, "  %s\t%s\n", spec.Name, spec.Description)
+	}
+	tw.Flush()
+	if c.globalFlags != nil {
+		var defaults bytes.Buffer
+		fs := flag.NewFlagSet("gator", flag.ContinueOnError)
+		c.globalFlags(fs)
+		fs.SetOutput(&defaults)
+		fs.PrintDefaults()
+		fmt.Fprintf(c.out, "\nGlobal flags (accepted before or after the command):\n%s", defaults.String())
+	}
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, `Run "gator help <command>" for details on a command.`)
+}
+
+// printHelp prints the description, usage, flags and subcommands of a command.
+func (c *commands) printHelp(path string, spec *commandSpec) {
+	synopsis := "gator " + path
+	if spec.Handler != nil {
+		if hasFlags(spec) {
+			synopsis += " [flags]"
+		}
+		if usage := spec.usage(); usage != "" {
+			synopsis += " " + usage
+		}
+	}
+	if len(spec.Subcommands) > 0 {
+		if spec.Handler != nil {
+			synopsis += "\n       gator " + path
+		}
+		synopsis += " <subcommand> ..."
+	}
+
+	fmt.Fprintf(c.out, "Usage: %s\n\n%s\n", synopsis, spec.Description)
+
+	if hasFlags(spec) {
+		var defaults bytes.Buffer
+		fs := spec.newFlagSet(path)
+		fs.SetOutput(&defaults)
+		fs.PrintDefaults()
+		fmt.Fprintf(c.out, "\nFlags:\n%s", defaults.String())
+	}
+
+	if len(spec.Subcommands) > 0 {
+		fmt.Fprintln(c.out, "\nSubcommands:")
+		tw := tabwriter.NewWriter(c.out, 0, 0, 3, ' ', 0)
+		for _, sub := range spec.Subcommands {
+			if !sub.Hidden {
+				fmt.Fprintf(tw, "  %s\t%s\n", sub.Name, sub.Description)
+			}
+		}
+		tw.Flush()
+	}
+}
+
+// hasFlags reports whether a command defines any flags.
+func hasFlags(spec *commandSpec) bool {
+	if spec.Flags == nil {
+		return false
+	}
+	found := false
+	spec.newFlagSet("").VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
+
+// handlerHelp prints the overview or the help for a single command.
+func (c *commands) handlerHelp(ctx context.Context, s *state, cmd command) error {
+	if len(cmd.Args) == 0 {
+		c.printOverview()
+		return nil
+	}
+	spec, ok := c.lookup(cmd.Args)
 	if !ok {
-		return errors.New("command not found")
+		return fmt.Errorf("%w: unknown command %q", errUsage, strings.Join(cmd.Args, " "))
 	}
-	return f(s, cmd)
+	c.printHelp(strings.Join(cmd.Args, " "), spec)
+	return nil
 }
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/praneeth-ayla/gator/internal/config"
+	"github.com/praneeth-ayla/gator/internal/database"
+	"github.com/praneeth-ayla/gator/internal/output"
+)
+
+// completer suggests values for an argument or flag during shell completion.
+type completer func(ctx context.Context, s *state) ([]string, error)
+
+// errNoDatabase is returned by completers that look values up in the
+// database when completion runs without one.
+var errNoDatabase = errors.New("no database to complete from")
+
+// completionScripts holds the shell glue for each supported shell. Each script
+// hands the words typed so far to the hidden __complete command, which does
+// all of the work, so new commands are completed without regenerating scripts.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for gator
+# Load with: source <(gator completion bash)
+_gator() {
+    local IFS=$'\n'
+    COMPREPLY=($(gator __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _gator gator
+`,
+	"zsh": `#compdef gator
+# zsh completion for gator
+# Load with: source <(gator completion zsh)
+_gator() {
+    local -a completions
+    completions=("${(@f)$(gator __complete "${words[@]:1:$((CURRENT-1))}" 2>/dev/null)}")
+    compadd -a completions
+}
+compdef _gator gator
+`,
+	"fish": `# fish completion for gator
+# Load with: gator completion fish | source
+function __gator_complete
+    set -l tokens (commandline -opc)
+    gator __complete $tokens[2..-1] (commandline -ct) 2>/dev/null
+end
+complete -c gator -f -a '(__gator_complete)'
+`,
+}
+
+// handlerCompletion prints the completion script for a shell.
+func handlerCompletion(ctx context.Context, s *state, cmd command) error {
+	script, ok := completionScripts[cmd.Args[0]]
+	if !ok {
+		return fmt.Errorf("%w: unsupported shell %q, use bash, zsh or fish", errUsage, cmd.Args[0])
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// handlerComplete prints completion candidates, one per line, for the words
+// typed after "gator". The last word is the one being completed.
+func (c *commands) handlerComplete(ctx context.Context, s *state, cmd command) error {
+	words := cmd.Args
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	for _, candidate := range c.complete(ctx, s, words[:len(words)-1], words[len(words)-1]) {
+		fmt.Println(candidate)
+	}
+	return nil
+}
+
+// complete returns the candidates for the partial word that follows the
+// completed words.
+func (c *commands) complete(ctx context.Context, s *state, done []string, partial string) []string {
+	// Skip global flags typed before the command name.
+	for len(done) > 0 && strings.HasPrefix(done[0], "-") {
+		name := strings.TrimLeft(done[0], "-")
+		done = done[1:]
+		if !strings.Contains(name, "=") && len(done) > 0 {
+			done = done[1:]
+		}
+	}
+
+	if len(done) == 0 {
+		if strings.HasPrefix(partial, "-") {
+			return filterPrefix(flagNames(c.flagSet("", &commandSpec{})), partial)
+		}
+		var names []string
+		for _, spec := range c.sortedCommands() {
+			names = append(names, spec.Name)
+		}
+		return filterPrefix(names, partial)
+	}
+
+	spec, ok := c.registeredCommands[done[0]]
+	if !ok {
+		return nil
+	}
+	rest := done[1:]
+	for len(rest) > 0 {
+		sub, ok := spec.subcommand(rest[0])
+		if !ok {
+			break
+		}
+		spec = sub
+		rest = rest[1:]
+	}
+
+	fs := c.flagSet("", spec)
+	if strings.HasPrefix(partial, "-") {
+		return filterPrefix(flagNames(fs), partial)
+	}
+
+	// Count positional arguments, skipping flags and their values, and note
+	// whether the partial word is the value of a flag.
+	positional := 0
+	awaitingFlag := ""
+	for _, word := range rest {
+		if awaitingFlag != "" {
+			awaitingFlag = ""
+			continue
+		}
+		if strings.HasPrefix(word, "-") && word != "-" {
+			name := strings.TrimLeft(word, "-")
+			if f := fs.Lookup(name); f != nil && !isBoolFlag(f) {
+				awaitingFlag = name
+			}
+			continue
+		}
+		positional++
+	}
+	if awaitingFlag != "" {
+		complete, ok := spec.FlagCompletions[awaitingFlag]
+		if !ok {
+			complete = c.globalFlagCompletions[awaitingFlag]
+		}
+		return runCompleter(ctx, s, complete, partial)
+	}
+
+	var candidates []string
+	// Before any argument, a group command can still take a subcommand.
+	if positional == 0 {
+		for _, sub := range spec.Subcommands {
+			if !sub.Hidden {
+				candidates = append(candidates, sub.Name)
+			}
+		}
+	}
+
+	var arg *argSpec
+	if positional < len(spec.Args) {
+		arg = &spec.Args[positional]
+	} else if n := len(spec.Args); n > 0 && spec.Args[n-1].Variadic {
+		arg = &spec.Args[n-1]
+	}
+	if arg != nil {
+		candidates = append(candidates, runCompleter(ctx, s, arg.Complete, "")...)
+	}
+
+	return filterPrefix(candidates, partial)
+}
+
+// runCompleter runs an optional completer and filters its results. Errors
+// are ignored: completion must never print anything but candidates.
+func runCompleter(ctx context.Context, s *state, complete completer, partial string) []string {
+	if complete == nil {
+		return nil
+	}
+	values, err := complete(ctx, s)
+	if err != nil {
+		return nil
+	}
+	return filterPrefix(values, partial)
+}
+
+// flagNames lists every flag in a FlagSet as "--name".
+func flagNames(fs *flag.FlagSet) []string {
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, "--"+f.Name) })
+	return names
+}
+
+// isBoolFlag reports whether a flag can be given without a value.
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// filterPrefix returns the sorted, de-duplicated values starting with prefix.
+func filterPrefix(values []string, prefix string) []string {
+	seen := map[string]bool{}
+	var matches []string
+	for _, value := range values {
+		if strings.HasPrefix(value, prefix) && !seen[value] {
+			seen[value] = true
+			matches = append(matches, value)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// completeShells suggests the shells completion scripts exist for.
+func completeShells(ctx context.Context, s *state) ([]string, error) {
+	shells := make([]string, 0, len(completionScripts))
+	for shell := range completionScripts {
+		shells = append(shells, shell)
+	}
+	return shells, nil
+}
+
+// completeOutputFormats suggests the values --output accepts.
+func completeOutputFormats(ctx context.Context, s *state) ([]string, error) {
+	return []string{output.Text, output.JSON, output.NDJSON, output.CSV, output.Table, output.Template + "="}, nil
+}
+
+// completeSettings suggests every setting key.
+func completeSettings(ctx context.Context, s *state) ([]string, error) {
+	keys := make([]string, 0, len(config.Settings))
+	for _, setting := range config.Settings {
+		keys = append(keys, setting.Key)
+	}
+	return keys, nil
+}
+
+// completeProfiles suggests every profile name.
+func completeProfiles(ctx context.Context, s *state) ([]string, error) {
+	var names []string
+	for _, profile := range s.cfg.Profiles() {
+		names = append(names, profile.Name)
+	}
+	return names, nil
+}
+
+// completeTokens suggests the names of the current user's API tokens.
+func completeTokens(ctx context.Context, s *state) ([]string, error) {
+	if s.db == nil {
+		return nil, errNoDatabase
+	}
+	user, _, err := loggedInUser(ctx, s, command{})
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := s.db.GetAPITokensForUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		names = append(names, token.Name)
+	}
+	return names, nil
+}
+
+// completeRoles suggests the roles a user can have.
+func completeRoles(ctx context.Context, s *state) ([]string, error) {
+	return []string{roleAdmin, roleMember}, nil
+}
+
+// completeUsers suggests every user name.
+func completeUsers(ctx context.Context, s *state) ([]string, error) {
+	if s.db == nil {
+		return nil, errNoDatabase
+	}
+	users, err := s.db.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, user.Name)
+	}
+	return names, nil
+}
+
+// completeFeedURLs suggests the URL of every registered feed.
+func completeFeedURLs(ctx context.Context, s *state) ([]string, error) {
+	if s.db == nil {
+		return nil, errNoDatabase
+	}
+	feeds, err := s.db.GetFeeds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, 0, len(feeds))
+	for _, feed := range feeds {
+		urls = append(urls, feed.Url)
+	}
+	return urls, nil
+}
+
+// completeFollowedURLs suggests the URLs of feeds the current user follows.
+func completeFollowedURLs(ctx context.Context, s *state) ([]string, error) {
+	rows, err := currentUserTaggedFollows(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, 0, len(rows))
+	for _, row := range rows {
+		urls = append(urls, row.FeedUrl)
+	}
+	return urls, nil
+}
+
+// completeTags suggests the current user's tags.
+func completeTags(ctx context.Context, s *state) ([]string, error) {
+	rows, err := currentUserTaggedFollows(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	for _, row := range rows {
+		if row.TagName.Valid {
+			tags = append(tags, row.TagName.String)
+		}
+	}
+	return tags, nil
+}
+
+// currentUserTaggedFollows loads the logged-in user's follows with their tags.
+func currentUserTaggedFollows(ctx context.Context, s *state) ([]database.GetTaggedFeedFollowsForUserRow, error) {
+	if s.db == nil {
+		return nil, errNoDatabase
+	}
+	user, _, err := loggedInUser(ctx, s, command{})
+	if err != nil {
+		return nil, err
+	}
+	return s.db.GetTaggedFeedFollowsForUser(ctx, user.ID)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/praneeth-ayla/gator/internal/storage"
+)
+
+// Error kinds. Handlers wrap or attach one of these so main can pick the exit
+// code; errors without a kind exit with 1.
+var (
+	// errUsage marks errors caused by invalid command-line input.
+	errUsage         = errors.New("invalid usage")
+	errNotLoggedIn   = errors.New("not logged in")
+	errNotFound      = errors.New("not found")
+	errAlreadyExists = errors.New("already exists")
+	errNetwork       = errors.New("network error")
+	errParse         = errors.New("parse error")
+	errAuth          = errors.New("authentication failed")
+	errPermission    = errors.New("permission denied")
+	errTimeout       = errors.New("timed out")
+	errInterrupted   = errors.New("interrupted")
+)
+
+// exitCodes maps each error kind to the status gator exits with.
+var exitCodes = []struct {
+	kind error
+	code int
+}{
+	{errUsage, 2},
+	{errNotLoggedIn, 3},
+	{errNotFound, 4},
+	{errAlreadyExists, 5},
+	{errNetwork, 6},
+	{errParse, 7},
+	{errAuth, 8},
+	{errPermission, 9},
+	// Like timeout(1) and shells after SIGINT.
+	{errTimeout, 124},
+	{errInterrupted, 130},
+}
+
+// exitCode returns the exit status for an error returned by a command.
+func exitCode(err error) int {
+	for _, e := range exitCodes {
+		if errors.Is(err, e.kind) {
+			return e.code
+		}
+	}
+	return 1
+}
+
+// kindError is an error of a given kind whose message already reads well on
+// its own, so the kind isn't repeated in it.
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string { return e.msg }
+func (e *kindError) Unwrap() error { return e.kind }
+
+// newError creates an error of the given kind with a formatted message.
+func newError(kind error, format string, args ...any) error {
+	return &kindError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
+
+// notFound turns sql.ErrNoRows into a not-found error with a friendly
+// message. Other errors are returned unchanged.
+func notFound(err error, format string, args ...any) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return newError(errNotFound, format, args...)
+	}
+	return err
+}
+
+// alreadyExists turns a unique constraint violation into an already-exists
+// error with a friendly message. Other errors are returned unchanged.
+func alreadyExists(err error, format string, args ...any) error {
+	if storage.IsUniqueViolation(err) {
+		return newError(errAlreadyExists, format, args...)
+	}
+	return err
+}
+
+// classify gives driver errors that reached main without a kind a generic
+// one, so raw database errors never reach the user.
+func classify(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows) && !errors.Is(err, errNotFound):
+		return newError(errNotFound, "not found")
+	case storage.IsUniqueViolation(err) && !errors.Is(err, errAlreadyExists):
+		return newError(errAlreadyExists, "already exists")
+	}
+	return err
+}
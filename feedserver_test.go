@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newFeedServer starts a local server serving the feed fixtures in testdata:
+// /rss.xml, /atom.xml and /broken.xml, plus /missing.xml which always 404s.
+func newFeedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	for _, name := range []string{"rss.xml", "atom.xml", "broken.xml"} {
+		path := "testdata/" + name
+		mux.HandleFunc("/"+name, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/xml")
+			http.ServeFile(w, r, path)
+		})
+	}
+	mux.HandleFunc("/missing.xml", http.NotFound)
+	// slow.xml never answers, so only cancelling the request ends it.
+	mux.HandleFunc("/slow.xml", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
@@ -0,0 +1,548 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"hash/crc32"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/praneeth-ayla/gator/internal/database"
+)
+
+// feverPath is where the Fever API is served; apps are pointed at it.
+const feverPath = "/fever/"
+
+// feverAPIVersion is the version of the Fever API gator speaks.
+const feverAPIVersion = 3
+
+// feverItemLimit is how many items one items request returns, as in Fever.
+const feverItemLimit = 50
+
+// feverTokenName names the API token that holds a user's Fever key, so it
+// shows up in "token list" and can be revoked with "token rm".
+const feverTokenName = "fever"
+
+// feverFaviconID and feverFavicon are the icon every feed gets: gator
+// doesn't fetch site icons, and apps expect each feed to name one.
+const (
+	feverFaviconID = 1
+	feverFavicon   = "image/gif;base64,R0lGODlhAQABAIAAAAAAAP///yH5BAEAAAAALAAAAAABAAEAAAIBRAA7"
+)
+
+// fever serves the Fever API, the sync protocol many mobile and desktop
+// feed readers speak, over the same posts, read and star state as the
+// commands. Groups are tags, items are posts numbered as in browse, and
+// saved items are starred posts.
+type fever struct {
+	s *state
+}
+
+// newFever routes the Fever API. Fever has a single endpoint that takes
+// every request as query or form arguments.
+func newFever(s *state) http.Handler {
+	fv := &fever{s: s}
+	return http.HandlerFunc(fv.serve)
+}
+
+// feverGroup, feverFeedsGroup, feverFeed, feverIcon and feverItem are
+// Fever's records, with its field names.
+type feverGroup struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type feverFeedsGroup struct {
+	GroupID int64  `json:"group_id"`
+	FeedIDs string `json:"feed_ids"`
+}
+
+type feverFeed struct {
+	ID                int64  `json:"id"`
+	FaviconID         int64  `json:"favicon_id"`
+	Title             string `json:"title"`
+	URL               string `json:"url"`
+	SiteURL           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"`
+}
+
+type feverIcon struct {
+	ID   int64  `json:"id"`
+	Data string `json:"data"`
+}
+
+type feverItem struct {
+	ID            int64  `json:"id"`
+	FeedID        int64  `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	HTML          string `json:"html"`
+	URL           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"`
+}
+
+// feverRequest is one call to the Fever endpoint: the user it authenticated
+// as and their follows, which most answers need.
+type feverRequest struct {
+	r     *http.Request
+	user  database.User
+	feeds []database.Feed
+	// numbers maps feed IDs to the numbers Fever knows feeds by.
+	numbers map[uuid.UUID]int64
+}
+
+// has reports whether the request names an argument, which Fever often
+// passes without a value, as in ?api&items.
+func (req *feverRequest) has(name string) bool {
+	_, ok := req.r.Form[name]
+	return ok
+}
+
+// serve answers a Fever request. Mark actions run first, so the lists
+// answered with them already reflect the change.
+func (fv *fever) serve(w http.ResponseWriter, r *http.Request) {
+	response := map[string]any{"api_version": feverAPIVersion, "auth": 0}
+	if err := r.ParseForm(); err != nil {
+		fv.fail(w, response, newError(errUsage, "%v", err))
+		return
+	}
+	user, err := fv.authenticate(r)
+	if errors.Is(err, errAuth) {
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+	if err != nil {
+		fv.fail(w, response, err)
+		return
+	}
+	response["auth"] = 1
+
+	req := &feverRequest{r: r, user: user, numbers: map[uuid.UUID]int64{}}
+	req.feeds, err = fv.s.db.GetFollowedFeedsForUser(r.Context(), user.ID)
+	if err != nil {
+		fv.fail(w, response, err)
+		return
+	}
+	var refreshed time.Time
+	for _, feed := range req.feeds {
+		req.numbers[feed.ID] = feed.Number
+		if feed.LastFetchedAt.Valid && feed.LastFetchedAt.Time.After(refreshed) {
+			refreshed = feed.LastFetchedAt.Time
+		}
+	}
+	response["last_refreshed_on_time"] = unixTime(refreshed)
+
+	if req.has("mark") {
+		if err := fv.mark(req); err != nil {
+			fv.fail(w, response, err)
+			return
+		}
+	}
+
+	sections := []struct {
+		name   string
+		answer func(*feverRequest, map[string]any) error
+	}{
+		{"groups", fv.groups},
+		{"feeds", fv.feedList},
+		{"favicons", fv.favicons},
+		{"items", fv.items},
+		{"links", fv.links},
+		{"unread_item_ids", fv.unreadItemIDs},
+		{"saved_item_ids", fv.savedItemIDs},
+	}
+	for _, section := range sections {
+		if !req.has(section.name) {
+			continue
+		}
+		if err := section.answer(req, response); err != nil {
+			fv.fail(w, response, err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// fail responds to a request that couldn't be answered. Fever has no error
+// format, so the status says what went wrong and the message comes along.
+func (fv *fever) fail(w http.ResponseWriter, response map[string]any, err error) {
+	status, _, message := httpError(err)
+	response["error"] = message
+	writeJSON(w, status, response)
+}
+
+// authenticate returns the user whose Fever key the request sends as
+// api_key.
+func (fv *fever) authenticate(r *http.Request) (database.User, error) {
+	key := strings.ToLower(strings.TrimSpace(r.Form.Get("api_key")))
+	if key == "" {
+		return database.User{}, newError(errAuth, "no Fever api_key")
+	}
+	user, _, err := storedTokenUser(r.Context(), fv.s, key, true)
+	return user, err
+}
+
+// groups answers with the user's tags as groups.
+func (fv *fever) groups(req *feverRequest, response map[string]any) error {
+	tagged, err := fv.feedsByTag(req)
+	if err != nil {
+		return err
+	}
+	groups := make([]feverGroup, 0, len(tagged))
+	for _, tag := range slices.Sorted(maps.Keys(tagged)) {
+		groups = append(groups, feverGroup{ID: feverGroupID(tag), Title: tag})
+	}
+	response["groups"] = groups
+	response["feeds_groups"] = feedsGroups(tagged)
+	return nil
+}
+
+// feedList answers with the feeds the user follows.
+func (fv *fever) feedList(req *feverRequest, response map[string]any) error {
+	tagged, err := fv.feedsByTag(req)
+	if err != nil {
+		return err
+	}
+	feeds := make([]feverFeed, 0, len(req.feeds))
+	for _, feed := range req.feeds {
+		feeds = append(feeds, feverFeed{
+			ID:                feed.Number,
+			FaviconID:         feverFaviconID,
+			Title:             feed.Name,
+			URL:               feed.Url,
+			SiteURL:           feed.Url,
+			LastUpdatedOnTime: unixTime(feed.LastFetchedAt.Time),
+		})
+	}
+	response["feeds"] = feeds
+	response["feeds_groups"] = feedsGroups(tagged)
+	return nil
+}
+
+// favicons answers with the one icon all feeds share.
+func (fv *fever) favicons(req *feverRequest, response map[string]any) error {
+	response["favicons"] = []feverIcon{{ID: feverFaviconID, Data: feverFavicon}}
+	return nil
+}
+
+// items answers with up to feverItemLimit posts: those with a number above
+// since_id, oldest first; those below max_id, newest first; those listed in
+// with_ids; or else the newest. Posts the user's rules hide are left out.
+func (fv *fever) items(req *feverRequest, response map[string]any) error {
+	ctx := req.r.Context()
+	var posts []database.GetPostsForUserRow
+	switch {
+	case req.has("with_ids"):
+		numbers, err := feverIDs(req.r.Form.Get("with_ids"))
+		if err != nil {
+			return err
+		}
+		for _, number := range numbers[:min(len(numbers), feverItemLimit)] {
+			row, err := fv.s.db.GetPostWithStateForUser(ctx, database.GetPostWithStateForUserParams{
+				UserID: req.user.ID,
+				Number: number,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			posts = append(posts, database.GetPostsForUserRow(row))
+		}
+	case req.has("since_id"):
+		sinceID, err := feverID(req.r.Form.Get("since_id"))
+		if err != nil {
+			return err
+		}
+		rows, err := fv.s.db.GetPostsForUserAfterNumber(ctx, database.GetPostsForUserAfterNumberParams{
+			UserID: req.user.ID,
+			Number: sinceID,
+			Limit:  feverItemLimit,
+		})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			posts = append(posts, database.GetPostsForUserRow(row))
+		}
+	default:
+		maxID := int64(math.MaxInt64)
+		if value := req.r.Form.Get("max_id"); value != "" && value != "0" {
+			id, err := feverID(value)
+			if err != nil {
+				return err
+			}
+			maxID = id
+		}
+		rows, err := fv.s.db.GetPostsForUserBeforeNumber(ctx, database.GetPostsForUserBeforeNumberParams{
+			UserID: req.user.ID,
+			Number: maxID,
+			Limit:  feverItemLimit,
+		})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			posts = append(posts, database.GetPostsForUserRow(row))
+		}
+	}
+
+	items := make([]feverItem, 0, len(posts))
+	for _, post := range posts {
+		view := newPostView(post)
+		items = append(items, feverItem{
+			ID:            view.Number,
+			FeedID:        req.numbers[post.FeedID],
+			Title:         view.Title,
+			Author:        view.Author,
+			HTML:          view.Description,
+			URL:           view.URL,
+			IsSaved:       feverBool(view.StarredAt != nil),
+			IsRead:        feverBool(view.ReadAt != nil),
+			CreatedOnTime: unixTime(view.PublishedAt),
+		})
+	}
+	total, err := fv.s.db.CountPostsForUser(ctx, req.user.ID)
+	if err != nil {
+		return err
+	}
+	response["items"] = items
+	response["total_items"] = total
+	return nil
+}
+
+// links answers with no hot links: gator doesn't rank links across feeds.
+func (fv *fever) links(req *feverRequest, response map[string]any) error {
+	response["links"] = []any{}
+	return nil
+}
+
+// unreadItemIDs answers with the numbers of every unread post.
+func (fv *fever) unreadItemIDs(req *feverRequest, response map[string]any) error {
+	numbers, err := fv.s.db.GetUnreadPostNumbersForUser(req.r.Context(), req.user.ID)
+	if err != nil {
+		return err
+	}
+	response["unread_item_ids"] = joinIDs(numbers)
+	return nil
+}
+
+// savedItemIDs answers with the numbers of every starred post.
+func (fv *fever) savedItemIDs(req *feverRequest, response map[string]any) error {
+	numbers, err := fv.s.db.GetStarredPostNumbersForUser(req.r.Context(), req.user.ID)
+	if err != nil {
+		return err
+	}
+	response["saved_item_ids"] = joinIDs(numbers)
+	return nil
+}
+
+// mark changes read or saved state: of one item (mark=item, as=read,
+// unread, saved or unsaved), or of every item in a feed or group stored
+// before a time (mark=feed or mark=group, as=read, before=<unix time>).
+// Group 0 is every feed. Comparing with when gator stored a post, rather
+// than its date, keeps posts fetched since the app's last sync unread.
+func (fv *fever) mark(req *feverRequest) error {
+	ctx := req.r.Context()
+	form := req.r.Form
+	id, err := feverID(form.Get("id"))
+	if err != nil {
+		return err
+	}
+	as := form.Get("as")
+
+	switch form.Get("mark") {
+	case "item":
+		post, err := fv.s.db.GetPostForUserByNumber(ctx, database.GetPostForUserByNumberParams{
+			UserID: req.user.ID,
+			Number: id,
+		})
+		if err != nil {
+			return notFound(err, "no post %d in the feeds you follow", id)
+		}
+		switch as {
+		case "read", "unread":
+			_, err = setPostRead(ctx, fv.s, req.user.ID, post.ID, as == "read")
+		case "saved", "unsaved":
+			_, err = setPostStarred(ctx, fv.s, req.user.ID, post.ID, as == "saved")
+		default:
+			return newError(errUsage, "can't mark an item as %q", as)
+		}
+		return err
+	case "feed", "group":
+		if as != "read" {
+			return newError(errUsage, "can't mark a %s as %q", form.Get("mark"), as)
+		}
+		before := time.Now()
+		if value := form.Get("before"); value != "" {
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return newError(errUsage, "before must be a Unix time, got %q", value)
+			}
+			before = time.Unix(seconds, 0)
+		}
+		feedIDs, err := fv.markedFeeds(req, form.Get("mark"), id)
+		if err != nil {
+			return err
+		}
+		posts, err := fv.s.db.GetUnreadPostsForUserBefore(ctx, database.GetUnreadPostsForUserBeforeParams{
+			UserID:    req.user.ID,
+			CreatedAt: before,
+		})
+		if err != nil {
+			return err
+		}
+		return inTx(ctx, fv.s, func(tx *state) error {
+			for _, post := range posts {
+				if !feedIDs[post.FeedID] {
+					continue
+				}
+				if _, err := setPostRead(ctx, tx, req.user.ID, post.ID, true); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	default:
+		return newError(errUsage, "can't mark %q", form.Get("mark"))
+	}
+}
+
+// markedFeeds returns the feeds a feed or group mark applies to.
+func (fv *fever) markedFeeds(req *feverRequest, kind string, id int64) (map[uuid.UUID]bool, error) {
+	feedIDs := map[uuid.UUID]bool{}
+	if kind == "feed" {
+		for _, feed := range req.feeds {
+			if feed.Number == id {
+				feedIDs[feed.ID] = true
+			}
+		}
+		if len(feedIDs) == 0 {
+			return nil, newError(errNotFound, "you don't follow feed %d", id)
+		}
+		return feedIDs, nil
+	}
+
+	if id == 0 {
+		for _, feed := range req.feeds {
+			feedIDs[feed.ID] = true
+		}
+		return feedIDs, nil
+	}
+	tagged, err := fv.feedsByTag(req)
+	if err != nil {
+		return nil, err
+	}
+	for tag, numbers := range tagged {
+		if feverGroupID(tag) != id {
+			continue
+		}
+		for _, feed := range req.feeds {
+			if slices.Contains(numbers, feed.Number) {
+				feedIDs[feed.ID] = true
+			}
+		}
+	}
+	if len(feedIDs) == 0 {
+		return nil, newError(errNotFound, "you have no group %d", id)
+	}
+	return feedIDs, nil
+}
+
+// feedsByTag returns the numbers of the user's feeds under each tag.
+func (fv *fever) feedsByTag(req *feverRequest) (map[string][]int64, error) {
+	rows, err := fv.s.db.GetTaggedFeedFollowsForUser(req.r.Context(), req.user.ID)
+	if err != nil {
+		return nil, err
+	}
+	byURL := map[string]int64{}
+	for _, feed := range req.feeds {
+		byURL[feed.Url] = feed.Number
+	}
+	tagged := map[string][]int64{}
+	for _, row := range rows {
+		if row.TagName.Valid {
+			tagged[row.TagName.String] = append(tagged[row.TagName.String], byURL[row.FeedUrl])
+		}
+	}
+	return tagged, nil
+}
+
+// feedsGroups lists the feeds in each group, as Fever answers alongside
+// groups and feeds.
+func feedsGroups(tagged map[string][]int64) []feverFeedsGroup {
+	groups := make([]feverFeedsGroup, 0, len(tagged))
+	for _, tag := range slices.Sorted(maps.Keys(tagged)) {
+		numbers := slices.Clone(tagged[tag])
+		slices.Sort(numbers)
+		groups = append(groups, feverFeedsGroup{GroupID: feverGroupID(tag), FeedIDs: joinIDs(numbers)})
+	}
+	return groups
+}
+
+// feverGroupID gives a tag a stable group ID. Tags have no numbers of their
+// own, so the ID is a hash of the name, kept above 0, which Fever uses for
+// every feed.
+func feverGroupID(tag string) int64 {
+	return int64(crc32.ChecksumIEEE([]byte(tag))) + 1
+}
+
+// feverID parses an item, feed or group ID argument.
+func feverID(value string) (int64, error) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, newError(errUsage, "Fever IDs are whole numbers, got %q", value)
+	}
+	return id, nil
+}
+
+// feverIDs parses a comma-separated list of IDs.
+func feverIDs(value string) ([]int64, error) {
+	var ids []int64
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		id, err := feverID(field)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// joinIDs formats IDs as the comma-separated string Fever uses for lists.
+func joinIDs(ids []int64) string {
+	fields := make([]string, len(ids))
+	for i, id := range ids {
+		fields[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(fields, ",")
+}
+
+// feverBool is Fever's 0 or 1 for a flag.
+func feverBool(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// unixTime is t in Unix seconds, or 0 for the zero time.
+func unixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
@@ -2,30 +2,45 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/praneeth-ayla/gator/internal/config"
 	"github.com/praneeth-ayla/gator/internal/database"
+	"github.com/praneeth-ayla/gator/internal/output"
 )
 
 // handlerLogin handles user login by setting the current user in the config.
-func handlerLogin(s *state, cmd command) error {
-
-	if len(cmd.Args) < 1 {
-		return errors.New("the login handler expects a single argument, the username")
-	}
-
+func handlerLogin(ctx context.Context, s *state, cmd command) error {
 	username := cmd.Args[0]
 	// Retrieve user from the database.
-	user, err := s.db.GetUser(context.Background(), username)
+	user, err := s.db.GetUser(ctx, username)
 	if err != nil {
-		return err
+		return notFound(err, "no user named %q, create one with \"gator register\"", username)
+	}
+
+	// Users with a password must give it. Password-less users can only log
+	// in while auth is optional.
+	switch {
+	case user.PasswordHash.Valid:
+		if err := checkPassword(user, "Password: "); err != nil {
+			return err
+		}
+	case s.cfg.Auth == config.AuthRequired:
+		return newError(errAuth, "%q has no password, but auth is required: ask an admin to run \"gator user passwd %s\"", username, username)
 	}
 
 	// Set the current user in the application configuration.
-	err = s.cfg.SetUser(user.Name)
+	err = saveLogin(ctx, s, user)
 	if err != nil {
 		return err
 	}
@@ -34,36 +49,58 @@ func handlerLogin(s *state, cmd command) error {
 	return nil
 }
 
-// handlerRegister handles new user registration.
-func handlerRegister(s *state, cmd command) error {
-	if len(cmd.Args) < 1 {
-		return errors.New("the register handler expects a single argument, the name")
-	}
+// handlerRegister handles new user registration, asking for a password when
+// --password is given or auth is required.
+func handlerRegister(ctx context.Context, s *state, cmd command) error {
 	name := cmd.Args[0]
 
+	var passwordHash sql.NullString
+	if cmd.Bool("password") || s.cfg.Auth == config.AuthRequired {
+		hash, err := readNewPassword()
+		if err != nil {
+			return err
+		}
+		passwordHash = hash
+	}
+
+	// The first user of a database administers it.
+	role := roleMember
+	admins, err := s.db.CountAdmins(ctx)
+	if err != nil {
+		return err
+	}
+	if admins == 0 {
+		role = roleAdmin
+	}
+
 	// Create a new user in the database.
-	user, err := s.db.CreateUser(context.Background(), database.CreateUserParams{
-		ID:        uuid.New(),
-		Name:      name,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	user, err := s.db.CreateUser(ctx, database.CreateUserParams{
+		ID:           uuid.New(),
+		Name:         name,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+		PasswordHash: passwordHash,
+		Role:         role,
 	})
 	if err != nil {
-		return err
+		return alreadyExists(err, "a user named %q already exists", name)
 	}
 
 	// Set the newly created user as the current user.
-	err = s.cfg.SetUser(user.Name)
+	err = saveLogin(ctx, s, user)
 	if err != nil {
 		return err
 	}
-	fmt.Println("the user was created", user)
+	fmt.Printf("the user was created: %s (%s)\n", user.Name, user.Role)
 	return nil
 }
 
-// handlerReset deletes all users from the database.
-func handlerReset(s *state, cmd command) error {
-	err := s.db.DeleteUsers(context.Background())
+// handlerReset deletes all users from the database, once confirmed.
+func handlerReset(ctx context.Context, s *state, cmd command, user database.User) error {
+	if err := confirm(cmd, "Delete every user and everything they own?"); err != nil {
+		return err
+	}
+	err := s.db.DeleteUsers(ctx)
 	if err != nil {
 		return err
 	}
@@ -71,76 +108,231 @@ func handlerReset(s *state, cmd command) error {
 }
 
 // handlerGetUsers retrieves and prints all users, marking the current user.
-func handlerGetUsers(s *state, cmd command) error {
-	users, err := s.db.GetUsers(context.Background())
+func handlerGetUsers(ctx context.Context, s *state, cmd command) error {
+	users, err := s.db.GetUsers(ctx)
 	if err != nil {
 		return err
 	}
 
-	currentUser := s.cfg.CurrentUserName
+	current, _, _ := currentUser(ctx, s, cmd)
 
-	// Iterate and print each user, indicating the current one.
+	views := make([]userView, 0, len(users))
 	for _, user := range users {
-		if user.Name == currentUser {
-			fmt.Printf("* %v (current)\n", user.Name)
-			continue
+		views = append(views, userView{
+			Name:      user.Name,
+			Role:      user.Role,
+			Current:   user.Name == current,
+			CreatedAt: user.CreatedAt,
+		})
+	}
+
+	// Print each user, indicating the current one and admins.
+	return render(cmd, views, func(user userView) {
+		var notes []string
+		if user.Current {
+			notes = append(notes, "current")
+		}
+		if user.Role == roleAdmin {
+			notes = append(notes, roleAdmin)
+		}
+		if len(notes) > 0 {
+			fmt.Printf("* %v (%s)\n", user.Name, strings.Join(notes, ", "))
+			return
 		}
 		fmt.Printf("* %v\n", user.Name)
+	})
+}
+
+// handlerWhoami reports the user commands act as and where that comes from.
+func handlerWhoami(ctx context.Context, s *state, cmd command) error {
+	name, source, err := currentUser(ctx, s, cmd)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return newError(errNotLoggedIn, "not logged in, run \"gator login <name>\" or \"gator register <name>\" first")
+	}
+	user, err := s.db.GetUser(ctx, name)
+	if err != nil {
+		return notFound(err, "acting as %q from %s, but that user doesn't exist", name, source)
+	}
+	if err := checkPicked(ctx, s, user); err != nil {
+		return err
 	}
 
-	return nil
+	view := whoamiView{Name: user.Name, Source: source, Profile: s.cfg.Profile}
+	return render(cmd, []whoamiView{view}, func(view whoamiView) {
+		if view.Profile != config.DefaultProfile {
+			fmt.Printf("%s (from %s, profile %s)\n", view.Name, view.Source, view.Profile)
+			return
+		}
+		fmt.Printf("%s (from %s)\n", view.Name, view.Source)
+	})
 }
 
-// handlerAgg continuously scrapes feeds at a specified interval.
-func handlerAgg(s *state, cmd command) error {
-	if len(cmd.Args) != 1 {
-		return fmt.Errorf("usage: %s ", cmd.Name)
+// handlerAgg continuously scrapes feeds at a specified interval, or scrapes
+// every feed a single time when given "once".
+func handlerAgg(ctx context.Context, s *state, cmd command) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Args[0] == "once" {
+		return aggregateOnce(ctx, s, format)
 	}
 
 	// Parse the duration for time between requests.
 	timeBetweenReqs, err := time.ParseDuration(cmd.Args[0])
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: interval must be a duration such as 1m or \"once\", got %q", errUsage, cmd.Args[0])
+	}
+
+	// A JSON array would never be closed while aggregating forever, so
+	// results are streamed one object per line instead.
+	if format.Name == output.JSON {
+		format.Name = output.NDJSON
 	}
+	enc := output.NewEncoder(os.Stdout, format)
 
 	// Create a new ticker that fires at the specified interval.
 	ticker := time.NewTicker(timeBetweenReqs)
-	// Continuously scrape feeds on each tick.
-	for ; ; <-ticker.C {
-		scrapeFeeds(s)
+	defer ticker.Stop()
+	// Continuously scrape feeds on each tick until cancelled.
+	for {
+		result, err := scrapeFeeds(ctx, s)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := reportScrape(enc, format, result, err); err != nil {
+			return err
+		}
+		if err := enc.Flush(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
 	}
 }
 
-// handlerAddFeed adds a new feed and creates a follow for the given user.
-func handlerAddFeed(s *state, cmd command, user database.User) error {
-	ctx := context.Background()
+// aggregateOnce scrapes each feed that isn't paused one time, fetching as
+// many at once as the concurrency setting allows, and reports them oldest
+// fetch first. A feed that fails to fetch is reported and skipped.
+func aggregateOnce(ctx context.Context, s *state, format output.Format) error {
+	all, err := s.db.GetFeeds(ctx)
+	if err != nil {
+		return err
+	}
+	var feeds []database.Feed
+	for _, feed := range all {
+		if !feed.Paused {
+			feeds = append(feeds, feed)
+		}
+	}
+	sort.SliceStable(feeds, func(i, j int) bool {
+		a, b := feeds[i].LastFetchedAt, feeds[j].LastFetchedAt
+		if a.Valid != b.Valid {
+			return !a.Valid
+		}
+		return a.Time.Before(b.Time)
+	})
+
+	results := make([]scrapeView, len(feeds))
+	errs := make([]error, len(feeds))
+	slots := make(chan struct{}, max(s.cfg.Concurrency, 1))
+	var wg sync.WaitGroup
+	for i, feed := range feeds {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i], errs[i] = scrapeFeed(ctx, s, feed)
+		}()
+	}
+	wg.Wait()
+	// Once cancelled, every remaining feed fails the same way, so there is
+	// nothing worth reporting.
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	enc := output.NewEncoder(os.Stdout, format)
+	for i := range feeds {
+		if err := reportScrape(enc, format, results[i], errs[i]); err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
 
+// reportScrape prints the outcome of scraping one feed, either as text or as
+// a record in the selected output format.
+func reportScrape(enc *output.Encoder, format output.Format, result scrapeView, scrapeErr error) error {
+	if scrapeErr != nil {
+		result.Error = scrapeErr.Error()
+	}
+	if !format.IsText() {
+		// Errors that happen before a feed is picked have nothing to report on.
+		if result.URL == "" {
+			log.Printf("couldn't scrape feed: %v", scrapeErr)
+			return nil
+		}
+		return enc.Encode(result)
+	}
+	if scrapeErr != nil {
+		log.Printf("couldn't scrape feed: %v", scrapeErr)
+		return nil
+	}
+	fmt.Printf("Feed %s collected, %d new posts\n", result.Feed, result.NewPosts)
+	return nil
+}
+
+// handlerAddFeed adds a new feed and creates a follow for the given user.
+// Both are written in one transaction, so a failed follow leaves no feed
+// behind.
+func handlerAddFeed(ctx context.Context, s *state, cmd command, user database.User) error {
 	name := cmd.Args[0]
 	url := cmd.Args[1]
 	if name == "" || url == "" {
-		return errors.New("add feed command requires name and url")
+		return fmt.Errorf("%w: add feed command requires name and url", errUsage)
 	}
 
-	// Create the feed in the database.
-	feed, err := s.db.CreateFeed(ctx, database.CreateFeedParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Name:      name,
-		Url:       url,
-		UserID:    user.ID,
-	})
-	if err != nil {
-		return err
-	}
+	var feed database.Feed
+	err := inTx(ctx, s, func(tx *state) error {
+		if err := tx.db.LockFeedNumbers(ctx); err != nil {
+			return err
+		}
 
-	// Create a feed follow for the user for this new feed.
-	_, err = s.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		UserID:    feed.UserID,
-		FeedID:    feed.ID,
+		// Create the feed in the database.
+		var err error
+		feed, err = tx.db.CreateFeed(ctx, database.CreateFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Name:      name,
+			Url:       url,
+			UserID:    user.ID,
+		})
+		if err != nil {
+			return alreadyExists(err, "a feed with url %s already exists, follow it with \"gator follow\"", url)
+		}
+
+		// Create a feed follow for the user for this new feed.
+		_, err = tx.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			UserID:    feed.UserID,
+			FeedID:    feed.ID,
+		})
+		if err != nil {
+			return alreadyExists(err, "you already follow %s", url)
+		}
+		return nil
 	})
 	if err != nil {
 		return err
@@ -152,48 +344,59 @@ func handlerAddFeed(s *state, cmd command, user database.User) error {
 }
 
 // handlerFeeds retrieves and prints all registered feeds.
-func handlerFeeds(s *state, cmd command) error {
-	ctx := context.Background()
-	feeds, err := s.db.GetFeeds(ctx)
+func handlerFeeds(ctx context.Context, s *state, cmd command) error {
+	views, err := feedViews(ctx, s)
 	if err != nil {
 		return err
 	}
 
-	// Iterate and print details for each feed.
-	for _, feed := range feeds {
-		user, err := s.db.GetUserById(ctx, feed.UserID)
-		if err != nil {
-			return err
+	// Print details for each feed, marking paused ones.
+	return render(cmd, views, func(feed feedView) {
+		if feed.Paused {
+			feed.Name += " (paused)"
 		}
 		fmt.Printf(`Feed Name: %v,
 Feed URL: %v,
 User Name: %v
-`, feed.Name, feed.Url, user.Name)
+`, feed.Name, feed.URL, feed.UserName)
+	})
+}
+
+// feedViews describes every registered feed with the name of the user who
+// added it.
+func feedViews(ctx context.Context, s *state) ([]feedView, error) {
+	feeds, err := s.db.GetFeeds(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	views := make([]feedView, 0, len(feeds))
+	for _, feed := range feeds {
+		user, err := s.db.GetUserById(ctx, feed.UserID)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, feedView{
+			Name:          feed.Name,
+			URL:           feed.Url,
+			UserName:      user.Name,
+			LastFetchedAt: nullTime(feed.LastFetchedAt.Valid, feed.LastFetchedAt.Time),
+			Paused:        feed.Paused,
+		})
+	}
+	return views, nil
 }
 
 // handlerFollow creates a feed follow for a given feed URL and current user.
-func handlerFollow(s *state, cmd command, user database.User) error {
-	ctx := context.Background()
-
-	url := cmd.Args[0]
-	// Get the feed by its URL.
-	feed, err := s.db.GetFeedByUrl(ctx, url)
+// A feed that isn't registered yet is added first, once fetching it shows
+// it's a feed.
+func handlerFollow(ctx context.Context, s *state, cmd command, user database.User) error {
+	follow, added, err := followFeed(ctx, s, user, cmd.Args[0], cmd.String("name"))
 	if err != nil {
 		return err
 	}
-	// Create the feed follow record.
-	follow, err := s.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		UserID:    user.ID,
-		FeedID:    feed.ID,
-	})
-	if err != nil {
-		return err
+	if added {
+		fmt.Printf("added feed %s\n", follow.FeedName)
 	}
 	fmt.Printf(`Feed Name: %v
 User Name: %v
@@ -202,37 +405,251 @@ User Name: %v
 	return nil
 }
 
+// followFeed makes user follow the feed at url. A feed nobody added yet is
+// fetched and added first, named name or else after its title; added
+// reports whether that happened.
+func followFeed(ctx context.Context, s *state, user database.User, url, name string) (follow database.CreateFeedFollowRow, added bool, err error) {
+	// Get the feed by its URL, fetching it first when it's new.
+	feed, err := s.db.GetFeedByUrl(ctx, url)
+	var newFeed *database.CreateFeedParams
+	if errors.Is(err, sql.ErrNoRows) {
+		params, fetchErr := newFeedParams(ctx, s, user, url, name)
+		if fetchErr != nil {
+			return follow, false, fetchErr
+		}
+		newFeed, err = &params, nil
+	}
+	if err != nil {
+		return follow, false, err
+	}
+
+	// Add a new feed and the follow together, so a failed follow leaves no
+	// feed behind.
+	err = inTx(ctx, s, func(tx *state) error {
+		if newFeed != nil {
+			if err := tx.db.LockFeedNumbers(ctx); err != nil {
+				return err
+			}
+			created, err := tx.db.CreateFeed(ctx, *newFeed)
+			if err != nil {
+				return alreadyExists(err, "a feed with url %s was just added, follow it again", url)
+			}
+			feed = created
+		}
+		// Create the feed follow record.
+		var err error
+		follow, err = tx.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			UserID:    user.ID,
+			FeedID:    feed.ID,
+		})
+		if err != nil {
+			return alreadyExists(err, "you already follow %s", url)
+		}
+		if newFeed != nil {
+			return nil
+		}
+
+		// The user's rules cover the posts the feed already has.
+		userRules, err := loadRules(ctx, tx, user.ID)
+		if err != nil {
+			return err
+		}
+		posts, err := tx.db.GetPostsForFeed(ctx, feed.ID)
+		if err != nil {
+			return err
+		}
+		return applyRulesToPosts(ctx, tx, user.ID, posts, userRules)
+	})
+	return follow, newFeed != nil && err == nil, err
+}
+
 // handlerFollowing retrieves and prints all feeds followed by the current user.
-func handlerFollowing(s *state, cmd command, user database.User) error {
-	ctx := context.Background()
+func handlerFollowing(ctx context.Context, s *state, cmd command, user database.User) error {
+	views, err := followViews(ctx, s, user)
+	if err != nil {
+		return err
+	}
+
+	// Print the name of each followed feed with the index unfollow takes.
+	return render(cmd, views, func(follow followView) {
+		fmt.Printf("%d. %s\n", follow.Index, follow.FeedName)
+	})
+}
 
+// followViews describes the user's follows, oldest first, numbered as
+// unfollow takes them.
+func followViews(ctx context.Context, s *state, user database.User) ([]followView, error) {
 	feedFollows, err := s.db.GetFeedFollowsForUser(ctx, user.ID)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
-	// Print the name of each followed feed.
-	for _, feedFollow := range feedFollows {
-		fmt.Println(feedFollow.FeedName)
+	views := make([]followView, 0, len(feedFollows))
+	for i, feedFollow := range feedFollows {
+		views = append(views, followView{
+			Index:      i + 1,
+			FeedName:   feedFollow.FeedName,
+			FeedURL:    feedFollow.FeedUrl,
+			UserName:   feedFollow.UserName,
+			FollowedAt: feedFollow.CreatedAt,
+		})
 	}
-
-	return nil
+	return views, nil
 }
 
-// handlerUnfollow deletes a feed follow for a given URL and current user.
-func handlerUnfollow(s *state, cmd command, user database.User) error {
-	ctx := context.Background()
-	url := cmd.Args[0]
-	username := s.cfg.CurrentUserName
+// handlerUnfollow deletes one of the current user's follows, given by feed
+// URL, feed name, or its index in the following list.
+func handlerUnfollow(ctx context.Context, s *state, cmd command, user database.User) error {
+	follow, err := findFollow(ctx, s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
 
 	// Delete the feed follow record.
-	err := s.db.DeleteFeedFollow(ctx, database.DeleteFeedFollowParams{
-		Url:  url,
-		Name: username,
+	deleted, err := s.db.DeleteFeedFollow(ctx, database.DeleteFeedFollowParams{
+		Url:  follow.FeedUrl,
+		Name: user.Name,
 	})
 	if err != nil {
 		return err
 	}
-
+	if deleted == 0 {
+		return newError(errNotFound, "you don't follow %s", follow.FeedUrl)
+	}
+	fmt.Printf("unfollowed %s\n", follow.FeedName)
 	return nil
 }
+
+// handlerBrowse prints the latest posts from the current user's followed feeds,
+// optionally limited to feeds carrying a tag.
+func handlerBrowse(ctx context.Context, s *state, cmd command, user database.User) error {
+	limit := 2
+	tag := cmd.String("tag")
+
+	if len(cmd.Args) > 0 {
+		parsed, err := strconv.Atoi(cmd.Args[0])
+		if err != nil || parsed < 1 {
+			return fmt.Errorf("%w: limit must be a positive number, got %q", errUsage, cmd.Args[0])
+		}
+		limit = parsed
+	}
+
+	views, err := listPosts(ctx, s, user, postQuery{Tag: tag, Limit: limit})
+	if err != nil {
+		return err
+	}
+
+	// Print each post with its feed and publish date.
+	color := useColor(s)
+	return render(cmd, views, func(post postView) {
+		fmt.Printf("[%d] %s from %s\n", post.Number, post.PublishedAt.Format("Mon Jan 2"), post.FeedName)
+		title := post.Title
+		if post.Highlighted && color {
+			title = highlight(title)
+		}
+		fmt.Printf("--- %s%s ---\n", title, postMarkers(post))
+		fmt.Printf("    %v\n", post.Description)
+		fmt.Printf("Link: %s\n", post.URL)
+		fmt.Println("=====================================")
+	})
+}
+
+// postQuery selects the posts listPosts returns: a page of the newest ones
+// from every followed feed, from feeds carrying Tag, or from one feed.
+type postQuery struct {
+	Tag    string
+	FeedID uuid.NullUUID
+	Limit  int
+	Offset int
+}
+
+// listPosts returns the user's posts matching q, leaving out the ones their
+// filter rules hide.
+func listPosts(ctx context.Context, s *state, user database.User, q postQuery) ([]postView, error) {
+	var posts []database.GetPostsForUserRow
+	switch {
+	case q.FeedID.Valid:
+		rows, err := s.db.GetPostsForUserByFeed(ctx, database.GetPostsForUserByFeedParams{
+			UserID: user.ID,
+			FeedID: q.FeedID.UUID,
+			Limit:  int32(q.Limit),
+			Offset: int32(q.Offset),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			posts = append(posts, database.GetPostsForUserRow(row))
+		}
+	case q.Tag != "":
+		rows, err := s.db.GetPostsForUserByTag(ctx, database.GetPostsForUserByTagParams{
+			UserID: user.ID,
+			Name:   q.Tag,
+			Limit:  int32(q.Limit),
+			Offset: int32(q.Offset),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			posts = append(posts, database.GetPostsForUserRow(row))
+		}
+	default:
+		rows, err := s.db.GetPostsForUser(ctx, database.GetPostsForUserParams{
+			UserID: user.ID,
+			Limit:  int32(q.Limit),
+			Offset: int32(q.Offset),
+		})
+		if err != nil {
+			return nil, err
+		}
+		posts = rows
+	}
+
+	views := make([]postView, 0, len(posts))
+	for _, post := range posts {
+		views = append(views, newPostView(post))
+	}
+	return views, nil
+}
+
+// newPostView combines a post with the per-user state stored for it. Posts
+// without a publish date fall back to when they were saved.
+func newPostView(post database.GetPostsForUserRow) postView {
+	view := postView{
+		ID:          post.ID.String(),
+		Number:      post.Number,
+		Title:       post.Title,
+		URL:         post.Url,
+		FeedName:    post.FeedName,
+		PublishedAt: post.CreatedAt,
+		Author:      post.Author.String,
+		Categories:  splitCategories(post.Categories),
+		Description: post.Description.String,
+		ReadAt:      nullTime(post.ReadAt.Valid, post.ReadAt.Time),
+		StarredAt:   nullTime(post.StarredAt.Valid, post.StarredAt.Time),
+		Highlighted: post.Highlighted,
+	}
+	if post.PublishedAt.Valid {
+		view.PublishedAt = post.PublishedAt.Time
+	}
+	return view
+}
+
+// postMarkers returns the read, starred and highlighted markers for a listed post.
+func postMarkers(post postView) string {
+	markers := ""
+	if post.Highlighted {
+		markers += " [!]"
+	}
+	if post.StarredAt != nil {
+		markers += " [starred]"
+	}
+	if post.ReadAt != nil {
+		markers += " [read]"
+	}
+	return markers
+}
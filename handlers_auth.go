@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/praneeth-ayla/gator/internal/auth"
+	"github.com/praneeth-ayla/gator/internal/database"
+)
+
+// envToken holds an API token that authenticates commands as its user.
+const envToken = "GATOR_TOKEN"
+
+// loginLifetime is how long a password login on the command line lasts.
+const loginLifetime = 365 * 24 * time.Hour
+
+// newSession starts a session for a user that lasts lifetime and returns its
+// token. Only the token's hash is stored.
+func newSession(ctx context.Context, s *state, userID uuid.UUID, lifetime time.Duration) (string, error) {
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.db.DeleteExpiredSessions(ctx, time.Now()); err != nil {
+		return "", err
+	}
+	err = s.db.CreateSession(ctx, database.CreateSessionParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(lifetime),
+		UserID:    userID,
+		TokenHash: hash,
+	})
+	return token, err
+}
+
+// hasLoginSession reports whether the config holds the session of a
+// password login as user that hasn't expired.
+func hasLoginSession(ctx context.Context, s *state, user database.User) (bool, error) {
+	if s.cfg.SessionToken == "" {
+		return false, nil
+	}
+	sessionUser, err := s.db.GetSessionUser(ctx, database.GetSessionUserParams{
+		TokenHash: auth.HashToken(s.cfg.SessionToken),
+		ExpiresAt: time.Now(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return sessionUser.ID == user.ID, nil
+}
+
+// saveLogin makes user the current user in the config, with a new login
+// session when they have a password.
+func saveLogin(ctx context.Context, s *state, user database.User) error {
+	token := ""
+	if user.PasswordHash.Valid {
+		var err error
+		token, err = newSession(ctx, s, user.ID, loginLifetime)
+		if err != nil {
+			return err
+		}
+	}
+	return s.cfg.SetLogin(user.Name, token)
+}
+
+// checkPassword prompts for a user's password and verifies it.
+func checkPassword(user database.User, prompt string) error {
+	password, err := readPassword(prompt)
+	if err != nil {
+		return err
+	}
+	err = auth.CheckPassword(user.PasswordHash.String, password)
+	if errors.Is(err, auth.ErrMismatch) {
+		return newError(errAuth, "wrong password for %q", user.Name)
+	}
+	return err
+}
+
+// handlerPasswd sets or changes the current user's password. A user logged
+// in through the config is logged in again with it, since a password makes
+// the config's user name alone no longer enough.
+func handlerPasswd(ctx context.Context, s *state, cmd command, user database.User) error {
+	if user.PasswordHash.Valid {
+		if err := checkPassword(user, "Current password: "); err != nil {
+			return err
+		}
+	}
+	hash, err := readNewPassword()
+	if err != nil {
+		return err
+	}
+
+	err = s.db.SetUserPassword(ctx, database.SetUserPasswordParams{
+		ID:           user.ID,
+		PasswordHash: hash,
+		UpdatedAt:    time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if s.cfg.CurrentUserName == user.Name {
+		user.PasswordHash = hash
+		if err := saveLogin(ctx, s, user); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("password set for %s\n", user.Name)
+	return nil
+}
+
+// tokenUser returns the user an API token belongs to and records that the
+// token was used. Fever keys aren't API tokens here: they only log apps in
+// to the Fever API.
+func tokenUser(ctx context.Context, s *state, token string) (database.User, database.ApiToken, error) {
+	return storedTokenUser(ctx, s, token, false)
+}
+
+// storedTokenUser looks up a token in api_tokens, where Fever keys are kept
+// too, accepting only Fever keys or only other tokens, and records that it
+// was used.
+func storedTokenUser(ctx context.Context, s *state, token string, fever bool) (database.User, database.ApiToken, error) {
+	apiToken, err := s.db.GetAPITokenByHash(ctx, auth.HashToken(token))
+	if errors.Is(err, sql.ErrNoRows) || err == nil && (apiToken.Name == feverTokenName) != fever {
+		return database.User{}, database.ApiToken{}, newError(errAuth, "%s is not a valid API token", envToken)
+	}
+	if err != nil {
+		return database.User{}, apiToken, err
+	}
+
+	err = s.db.MarkAPITokenUsed(ctx, database.MarkAPITokenUsedParams{
+		ID:         apiToken.ID,
+		LastUsedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return database.User{}, apiToken, err
+	}
+	user, err := s.db.GetUserById(ctx, apiToken.UserID)
+	return user, apiToken, err
+}
+
+// handlerTokens lists the current user's API tokens.
+func handlerTokens(ctx context.Context, s *state, cmd command, user database.User) error {
+	tokens, err := s.db.GetAPITokensForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	views := make([]tokenView, 0, len(tokens))
+	for _, token := range tokens {
+		views = append(views, tokenView{
+			Name:       token.Name,
+			CreatedAt:  token.CreatedAt,
+			LastUsedAt: nullTime(token.LastUsedAt.Valid, token.LastUsedAt.Time),
+		})
+	}
+	return render(cmd, views, func(token tokenView) {
+		lastUsed := "never used"
+		if token.LastUsedAt != nil {
+			lastUsed = "last used " + token.LastUsedAt.Format(time.DateTime)
+		}
+		fmt.Printf("%s: created %s, %s\n", token.Name, token.CreatedAt.Format(time.DateTime), lastUsed)
+	})
+}
+
+// handlerTokenCreate creates an API token for the current user and prints it
+// once.
+func handlerTokenCreate(ctx context.Context, s *state, cmd command, user database.User) error {
+	name := cmd.Args[0]
+	if name == feverTokenName {
+		return newError(errUsage, "%q is kept for Fever apps: set it with \"gator token fever\"", name)
+	}
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.CreateAPIToken(ctx, database.CreateAPITokenParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UserID:    user.ID,
+		Name:      name,
+		TokenHash: hash,
+	})
+	if err != nil {
+		return alreadyExists(err, "you already have a token named %q", name)
+	}
+
+	fmt.Println(token)
+	fmt.Fprintf(os.Stderr, "this token won't be shown again: run commands with %s=<token> to act as %s\n", envToken, user.Name)
+	return nil
+}
+
+// handlerTokenRemove revokes one of the current user's API tokens.
+func handlerTokenRemove(ctx context.Context, s *state, cmd command, user database.User) error {
+	removed, err := s.db.DeleteAPIToken(ctx, database.DeleteAPITokenParams{
+		UserID: user.ID,
+		Name:   cmd.Args[0],
+	})
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return newError(errNotFound, "you have no token named %q", cmd.Args[0])
+	}
+	fmt.Printf("revoked token %s\n", cmd.Args[0])
+	return nil
+}
+
+// handlerTokenFever sets the password Fever apps log in with. Fever sends
+// the MD5 of "name:password" as its key, so the key is stored as an API
+// token named fever, replacing any earlier one, rather than reusing the
+// user's password.
+func handlerTokenFever(ctx context.Context, s *state, cmd command, user database.User) error {
+	password, err := readPassword("Fever password: ")
+	if err != nil {
+		return err
+	}
+	if len(password) < auth.MinPasswordLength {
+		return newError(errUsage, "password must be at least %d characters", auth.MinPasswordLength)
+	}
+
+	err = inTx(ctx, s, func(tx *state) error {
+		_, err := tx.db.DeleteAPIToken(ctx, database.DeleteAPITokenParams{
+			UserID: user.ID,
+			Name:   feverTokenName,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tx.db.CreateAPIToken(ctx, database.CreateAPITokenParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UserID:    user.ID,
+			Name:      feverTokenName,
+			TokenHash: auth.HashToken(auth.FeverKey(user.Name, password)),
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Fever password set: log in as %s at http://<serve address>%s\n", user.Name, feverPath)
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/praneeth-ayla/gator/internal/config"
+)
+
+// handlerConfigList lists every setting with its effective value and where
+// that value comes from.
+func handlerConfigList(ctx context.Context, s *state, cmd command) error {
+	views := make([]settingView, 0, len(config.Settings))
+	for _, setting := range config.Settings {
+		value, source, err := s.cfg.Get(setting.Key)
+		if err != nil {
+			return err
+		}
+		views = append(views, settingView{
+			Key:         setting.Key,
+			Value:       value,
+			Source:      string(source),
+			Description: setting.Description,
+		})
+	}
+	return render(cmd, views, func(setting settingView) {
+		fmt.Printf("%s = %s (%s)\n", setting.Key, setting.Value, setting.Source)
+	})
+}
+
+// handlerConfigGet prints the effective value of one setting.
+func handlerConfigGet(ctx context.Context, s *state, cmd command) error {
+	value, _, err := s.cfg.Get(cmd.Args[0])
+	if err != nil {
+		return newError(errUsage, "%v", err)
+	}
+	fmt.Println(value)
+	return nil
+}
+
+// handlerConfigSet validates a value and writes it to the config file.
+func handlerConfigSet(ctx context.Context, s *state, cmd command) error {
+	key, value := cmd.Args[0], cmd.Args[1]
+	if err := config.Validate(key, value); err != nil {
+		return newError(errUsage, "%v", err)
+	}
+	if err := s.cfg.Set(key, value); err != nil {
+		return err
+	}
+	fmt.Printf("set %s in %s\n", key, s.cfg.Path())
+	warnOverridden(s, key)
+	return nil
+}
+
+// handlerConfigUnset removes a setting from the config file so its default
+// applies again.
+func handlerConfigUnset(ctx context.Context, s *state, cmd command) error {
+	key := cmd.Args[0]
+	setting, err := config.Lookup(key)
+	if err != nil {
+		return newError(errUsage, "%v", err)
+	}
+	if setting.Required {
+		return newError(errUsage, "%s is required and can't be unset", key)
+	}
+	if err := s.cfg.Unset(key); err != nil {
+		return err
+	}
+	fmt.Printf("unset %s in %s\n", key, s.cfg.Path())
+	warnOverridden(s, key)
+	return nil
+}
+
+// handlerConfigPath prints the config file in use.
+func handlerConfigPath(ctx context.Context, s *state, cmd command) error {
+	fmt.Println(s.cfg.Path())
+	return nil
+}
+
+// warnOverridden tells the user when an environment variable hides the
+// value just written to the file.
+func warnOverridden(s *state, key string) {
+	setting, _ := config.Lookup(key)
+	if _, source, _ := s.cfg.Get(key); source == config.SourceEnv {
+		fmt.Printf("note: %s is overridden by %s\n", key, setting.Env)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/praneeth-ayla/gator/internal/database"
+)
+
+// canManageFeed reports whether user may change a feed everyone shares: the
+// user who added it and admins can.
+func canManageFeed(user database.User, feed database.Feed) bool {
+	return feed.UserID == user.ID || user.Role == roleAdmin
+}
+
+// manageableFeed looks a feed up by URL and checks that user may change it.
+func manageableFeed(ctx context.Context, s *state, user database.User, feedURL string) (database.Feed, error) {
+	feed, err := s.db.GetFeedByUrl(ctx, feedURL)
+	if err != nil {
+		return database.Feed{}, notFound(err, "no feed with url %s", feedURL)
+	}
+	if !canManageFeed(user, feed) {
+		return database.Feed{}, newError(errPermission, "only the user who added %s or an admin can change it", feedURL)
+	}
+	return feed, nil
+}
+
+// validateFeedURL checks that a feed URL is an absolute http or https URL.
+func validateFeedURL(feedURL string) error {
+	u, err := url.Parse(feedURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return newError(errUsage, "%q isn't a feed URL: use an http or https URL", feedURL)
+	}
+	return nil
+}
+
+// newFeedParams describes a feed for a URL nobody added yet. The URL is
+// fetched first, so only working feeds are added, and the feed's own title
+// names it unless a name is given.
+func newFeedParams(ctx context.Context, s *state, user database.User, feedURL, name string) (database.CreateFeedParams, error) {
+	if err := validateFeedURL(feedURL); err != nil {
+		return database.CreateFeedParams{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.FetchTimeout)
+	defer cancel()
+	fetched, err := fetchFeed(ctx, feedURL, s.cfg.UserAgent)
+	if err != nil {
+		return database.CreateFeedParams{}, err
+	}
+	if name == "" {
+		name = strings.TrimSpace(fetched.Channel.Title)
+	}
+	if name == "" {
+		name = feedURL
+	}
+
+	return database.CreateFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name:      name,
+		Url:       feedURL,
+		UserID:    user.ID,
+	}, nil
+}
+
+// findFollow resolves what unfollow was given to one of user's follows: the
+// index shown by following, a feed URL, or a feed name.
+func findFollow(ctx context.Context, s *state, user database.User, target string) (database.GetFeedFollowsForUserRow, error) {
+	follows, err := s.db.GetFeedFollowsForUser(ctx, user.ID)
+	if err != nil {
+		return database.GetFeedFollowsForUserRow{}, err
+	}
+
+	if index, err := strconv.Atoi(target); err == nil {
+		if index < 1 || index > len(follows) {
+			return database.GetFeedFollowsForUserRow{}, newError(errNotFound, "no follow number %d, \"gator following\" lists %d", index, len(follows))
+		}
+		return follows[index-1], nil
+	}
+	for _, follow := range follows {
+		if follow.FeedUrl == target {
+			return follow, nil
+		}
+	}
+
+	var matches []database.GetFeedFollowsForUserRow
+	for _, follow := range follows {
+		if strings.EqualFold(follow.FeedName, target) {
+			matches = append(matches, follow)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return database.GetFeedFollowsForUserRow{}, newError(errNotFound, "you don't follow a feed with url or name %q", target)
+	case 1:
+		return matches[0], nil
+	}
+	urls := make([]string, 0, len(matches))
+	for _, match := range matches {
+		urls = append(urls, match.FeedUrl)
+	}
+	return database.GetFeedFollowsForUserRow{}, newError(errUsage, "you follow several feeds named %q, give a URL instead: %s", target, strings.Join(urls, ", "))
+}
+
+// handlerFeedRename changes the name a feed is shown with.
+func handlerFeedRename(ctx context.Context, s *state, cmd command, user database.User) error {
+	feed, err := manageableFeed(ctx, s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	name := cmd.Args[1]
+	if name == "" {
+		return newError(errUsage, "a feed name can't be empty")
+	}
+
+	err = s.db.RenameFeed(ctx, database.RenameFeedParams{
+		ID:        feed.ID,
+		Name:      name,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("renamed %s to %s\n", feed.Name, name)
+	return nil
+}
+
+// handlerFeedSetURL points a feed at a new URL, e.g. after a site moved.
+// Follows, tags and posts stay with the feed.
+func handlerFeedSetURL(ctx context.Context, s *state, cmd command, user database.User) error {
+	feed, err := manageableFeed(ctx, s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	newURL := cmd.Args[1]
+	if err := validateFeedURL(newURL); err != nil {
+		return err
+	}
+
+	err = s.db.SetFeedUrl(ctx, database.SetFeedUrlParams{
+		ID:        feed.ID,
+		Url:       newURL,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		return alreadyExists(err, "a feed with url %s already exists", newURL)
+	}
+	fmt.Printf("%s now fetches %s\n", feed.Name, newURL)
+	return nil
+}
+
+// handlerFeedRemove deletes a feed with its posts and everyone's follows of
+// it, once confirmed.
+func handlerFeedRemove(ctx context.Context, s *state, cmd command, user database.User) error {
+	feed, err := manageableFeed(ctx, s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	followers, err := s.db.GetFollowerIDsForFeed(ctx, feed.ID)
+	if err != nil {
+		return err
+	}
+	if err := confirm(cmd, "Delete %s with its posts and %d follows?", feed.Name, len(followers)); err != nil {
+		return err
+	}
+
+	if err := s.db.DeleteFeed(ctx, feed.ID); err != nil {
+		return err
+	}
+	fmt.Printf("deleted %s\n", feed.Name)
+	return nil
+}
+
+// handlerFeedPause stops a feed from being fetched until it's resumed.
+func handlerFeedPause(ctx context.Context, s *state, cmd command, user database.User) error {
+	return setFeedPaused(ctx, s, cmd, user, true)
+}
+
+// handlerFeedResume fetches a paused feed again.
+func handlerFeedResume(ctx context.Context, s *state, cmd command, user database.User) error {
+	return setFeedPaused(ctx, s, cmd, user, false)
+}
+
+// setFeedPaused pauses or resumes fetching the feed named by the command.
+func setFeedPaused(ctx context.Context, s *state, cmd command, user database.User, paused bool) error {
+	feed, err := manageableFeed(ctx, s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	verb := "resumed"
+	if paused {
+		verb = "paused"
+	}
+	if feed.Paused == paused {
+		fmt.Printf("%s is already %s\n", feed.Name, verb)
+		return nil
+	}
+
+	err = s.db.SetFeedPaused(ctx, database.SetFeedPausedParams{
+		ID:        feed.ID,
+		Paused:    paused,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %s\n", verb, feed.Name)
+	return nil
+}
+
+// handlerFeedTransfer hands a feed over to another user.
+func handlerFeedTransfer(ctx context.Context, s *state, cmd command, user database.User) error {
+	feed, err := manageableFeed(ctx, s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	name := cmd.Args[1]
+	owner, err := s.db.GetUser(ctx, name)
+	if err != nil {
+		return notFound(err, "no user named %q", name)
+	}
+
+	err = s.db.SetFeedOwner(ctx, database.SetFeedOwnerParams{
+		ID:        feed.ID,
+		UserID:    owner.ID,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s now owns %s\n", owner.Name, feed.Name)
+	return nil
+}
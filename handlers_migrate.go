@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// handlerMigrateUp applies every pending migration.
+func handlerMigrateUp(ctx context.Context, s *state, cmd command) error {
+	migrator, err := newMigrator(s.db)
+	if err != nil {
+		return err
+	}
+
+	ran, err := migrator.Up(ctx)
+	for _, migration := range ran {
+		fmt.Printf("applied %s\n", migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(ran) == 0 {
+		fmt.Println("database is up to date")
+	}
+	return nil
+}
+
+// handlerMigrateDown rolls back the most recent migration.
+func handlerMigrateDown(ctx context.Context, s *state, cmd command) error {
+	migrator, err := newMigrator(s.db)
+	if err != nil {
+		return err
+	}
+
+	migration, err := migrator.Down(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("rolled back %s\n", migration.Name)
+	return nil
+}
+
+// handlerMigrateTo migrates up or down to a specific version.
+func handlerMigrateTo(ctx context.Context, s *state, cmd command) error {
+	target, err := strconv.ParseInt(cmd.Args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid version %q", errUsage, cmd.Args[0])
+	}
+
+	migrator, err := newMigrator(s.db)
+	if err != nil {
+		return err
+	}
+
+	ran, err := migrator.To(ctx, target)
+	for _, migration := range ran {
+		fmt.Printf("ran %s\n", migration.Name)
+	}
+	return err
+}
+
+// handlerMigrateStatus lists every migration and whether it has been applied.
+func handlerMigrateStatus(ctx context.Context, s *state, cmd command) error {
+	migrator, err := newMigrator(s.db)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	views := make([]migrationView, 0, len(statuses))
+	for _, status := range statuses {
+		views = append(views, migrationView{
+			Version:   status.Migration.Version,
+			Name:      status.Migration.Name,
+			Applied:   status.Applied,
+			AppliedAt: nullTime(status.Applied, status.AppliedAt),
+		})
+	}
+
+	return render(cmd, views, func(status migrationView) {
+		applied := "pending"
+		if status.Applied {
+			applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%-35s %s\n", status.Name, applied)
+	})
+}
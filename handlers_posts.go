@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/praneeth-ayla/gator/internal/database"
+)
+
+// handlerRead marks posts as read by the numbers shown in browse.
+func handlerRead(ctx context.Context, s *state, cmd command, user database.User) error {
+	return updatePosts(ctx, s, cmd, user, "marked %d as read", func(ctx context.Context, tx *state, post database.Post) error {
+		_, err := setPostRead(ctx, tx, user.ID, post.ID, true)
+		return err
+	})
+}
+
+// handlerUnread marks posts as unread again.
+func handlerUnread(ctx context.Context, s *state, cmd command, user database.User) error {
+	return updatePosts(ctx, s, cmd, user, "marked %d as unread", func(ctx context.Context, tx *state, post database.Post) error {
+		_, err := setPostRead(ctx, tx, user.ID, post.ID, false)
+		return err
+	})
+}
+
+// handlerStar stars posts by the numbers shown in browse.
+func handlerStar(ctx context.Context, s *state, cmd command, user database.User) error {
+	return updatePosts(ctx, s, cmd, user, "starred %d", func(ctx context.Context, tx *state, post database.Post) error {
+		_, err := setPostStarred(ctx, tx, user.ID, post.ID, true)
+		return err
+	})
+}
+
+// handlerUnstar removes the star from posts.
+func handlerUnstar(ctx context.Context, s *state, cmd command, user database.User) error {
+	return updatePosts(ctx, s, cmd, user, "unstarred %d", func(ctx context.Context, tx *state, post database.Post) error {
+		_, err := setPostStarred(ctx, tx, user.ID, post.ID, false)
+		return err
+	})
+}
+
+// updatePosts looks up every post number given as an argument among the
+// user's followed feeds, then applies update to each in one transaction.
+// All numbers are checked before any post is changed.
+func updatePosts(ctx context.Context, s *state, cmd command, user database.User, done string, update func(context.Context, *state, database.Post) error) error {
+	posts := make([]database.Post, 0, len(cmd.Args))
+	for _, arg := range cmd.Args {
+		number, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || number < 1 {
+			return fmt.Errorf("%w: post number must be a positive number, got %q", errUsage, arg)
+		}
+		post, err := s.db.GetPostForUserByNumber(ctx, database.GetPostForUserByNumberParams{
+			UserID: user.ID,
+			Number: number,
+		})
+		if err != nil {
+			return notFound(err, "no post %d in the feeds you follow", number)
+		}
+		posts = append(posts, post)
+	}
+
+	err := inTx(ctx, s, func(tx *state) error {
+		for _, post := range posts {
+			if err := update(ctx, tx, post); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		fmt.Printf(done+"\n", post.Number)
+	}
+	return nil
+}
+
+// setPostRead marks a post read or unread for a user and returns when it
+// was read, which is invalid once it's unread.
+func setPostRead(ctx context.Context, s *state, userID, postID uuid.UUID, read bool) (sql.NullTime, error) {
+	readAt := sql.NullTime{}
+	if read {
+		readAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+	err := s.db.SetPostRead(ctx, database.SetPostReadParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    userID,
+		PostID:    postID,
+		ReadAt:    readAt,
+	})
+	return readAt, err
+}
+
+// setPostStarred stars or unstars a post for a user and returns when it was
+// starred, which is invalid once it's unstarred.
+func setPostStarred(ctx context.Context, s *state, userID, postID uuid.UUID, starred bool) (sql.NullTime, error) {
+	starredAt := sql.NullTime{}
+	if starred {
+		starredAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+	err := s.db.SetPostStarred(ctx, database.SetPostStarredParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    userID,
+		PostID:    postID,
+		StarredAt: starredAt,
+	})
+	return starredAt, err
+}
+
+// postByNumber describes a post from the user's follows by the number
+// browse shows, with their state for it. Posts the user's rules hide
+// aren't found, as browse leaves them out.
+func postByNumber(ctx context.Context, s *state, user database.User, number int64) (postView, error) {
+	row, err := s.db.GetPostWithStateForUser(ctx, database.GetPostWithStateForUserParams{
+		UserID: user.ID,
+		Number: number,
+	})
+	if err != nil {
+		return postView{}, notFound(err, "no post %d in the feeds you follow", number)
+	}
+	return newPostView(database.GetPostsForUserRow(row)), nil
+}
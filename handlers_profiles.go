@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/praneeth-ayla/gator/internal/config"
+)
+
+// handlerProfiles lists the profiles in the config file, marking the one in
+// use.
+func handlerProfiles(ctx context.Context, s *state, cmd command) error {
+	profiles := s.cfg.Profiles()
+	views := make([]profileView, 0, len(profiles))
+	for _, profile := range profiles {
+		views = append(views, profileView{
+			Name:     profile.Name,
+			DbURL:    profile.DbURL,
+			UserName: profile.CurrentUserName,
+			Active:   profile.Active,
+			Saved:    profile.Saved,
+		})
+	}
+	return render(cmd, views, func(profile profileView) {
+		marker := " "
+		if profile.Active {
+			marker = "*"
+		}
+		user := profile.UserName
+		if user == "" {
+			user = "not logged in"
+		}
+		fmt.Printf("%s %s: %s (%s)\n", marker, profile.Name, profile.DbURL, user)
+	})
+}
+
+// handlerProfileUse selects the profile later runs use.
+func handlerProfileUse(ctx context.Context, s *state, cmd command) error {
+	if _, ok := findProfile(s, cmd.Args[0]); !ok {
+		return newError(errNotFound, "no profile named %q, add one with \"gator profile add\"", cmd.Args[0])
+	}
+	if err := s.cfg.UseProfile(cmd.Args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("using profile %s\n", cmd.Args[0])
+	return nil
+}
+
+// handlerProfileAdd creates a profile with its own database URL.
+func handlerProfileAdd(ctx context.Context, s *state, cmd command) error {
+	name, dbURL := cmd.Args[0], cmd.Args[1]
+	if _, ok := findProfile(s, name); ok {
+		return newError(errAlreadyExists, "a profile named %q already exists", name)
+	}
+	if err := s.cfg.AddProfile(name, dbURL); err != nil {
+		return newError(errUsage, "%v", err)
+	}
+	fmt.Printf("added profile %s, switch to it with \"gator profile use %s\"\n", name, name)
+	return nil
+}
+
+// handlerProfileRemove deletes a profile from the config file.
+func handlerProfileRemove(ctx context.Context, s *state, cmd command) error {
+	name := cmd.Args[0]
+	profile, ok := findProfile(s, name)
+	switch {
+	case !ok:
+		return newError(errNotFound, "no profile named %q", name)
+	case name == config.DefaultProfile:
+		return newError(errUsage, "the %s profile can't be removed", name)
+	case profile.Saved:
+		return newError(errUsage, "profile %q is in use: switch to another one first", name)
+	}
+	if err := s.cfg.RemoveProfile(name); err != nil {
+		return err
+	}
+	fmt.Printf("removed profile %s\n", name)
+	return nil
+}
+
+// findProfile looks up a profile of the config file by name.
+func findProfile(s *state, name string) (config.Profile, bool) {
+	for _, profile := range s.cfg.Profiles() {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return config.Profile{}, false
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/praneeth-ayla/gator/internal/database"
+	"github.com/praneeth-ayla/gator/internal/rules"
+)
+
+// handlerRules prints every filter rule owned by the current user.
+func handlerRules(ctx context.Context, s *state, cmd command, user database.User) error {
+	dbRules, err := s.db.GetFilterRulesForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	views := make([]ruleView, 0, len(dbRules))
+	for _, rule := range dbRules {
+		view := ruleView{
+			ID:        rule.ID.String(),
+			Field:     rule.Field,
+			MatchType: rule.MatchType,
+			Pattern:   rule.Pattern,
+			Action:    rule.Action,
+		}
+		if rule.FeedID.Valid {
+			feed, err := s.db.GetFeedById(ctx, rule.FeedID.UUID)
+			if err != nil {
+				return err
+			}
+			view.Feed = feed.Url
+		}
+		views = append(views, view)
+	}
+
+	return render(cmd, views, func(rule ruleView) {
+		scope := rule.Feed
+		if scope == "" {
+			scope = "all feeds"
+		}
+		fmt.Printf("%s: %s %s %q -> %s (%s)\n",
+			rule.ID, rule.Field, rule.MatchType, rule.Pattern, rule.Action, scope)
+	})
+}
+
+// handlerRulesAdd validates and stores a new rule, optionally scoped to one
+// feed with --feed.
+func handlerRulesAdd(ctx context.Context, s *state, cmd command, user database.User) error {
+	field, matchType, pattern, action := cmd.Args[0], cmd.Args[1], cmd.Args[2], cmd.Args[3]
+
+	feedID := uuid.NullUUID{}
+	if feedURL := cmd.String("feed"); feedURL != "" {
+		feed, err := s.db.GetFeedByUrl(ctx, feedURL)
+		if err != nil {
+			return notFound(err, "feed %s not found", feedURL)
+		}
+		feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	// Compile up front so invalid rules never reach the database.
+	if _, err := rules.Compile(uuid.Nil, feedID, field, matchType, pattern, action); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	// The rule covers the posts already in the user's follows, not just new
+	// ones, so it's stored and applied to them together.
+	var rule database.FilterRule
+	err := inTx(ctx, s, func(tx *state) error {
+		var err error
+		rule, err = tx.db.CreateFilterRule(ctx, database.CreateFilterRuleParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			UserID:    user.ID,
+			FeedID:    feedID,
+			Field:     field,
+			MatchType: matchType,
+			Pattern:   pattern,
+			Action:    action,
+		})
+		if err != nil {
+			return err
+		}
+		compiled, err := rules.Compile(rule.ID, rule.FeedID, rule.Field, rule.MatchType, rule.Pattern, rule.Action)
+		if err != nil {
+			return err
+		}
+		posts, err := tx.db.GetFollowedPostsForUser(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		return applyRulesToPosts(ctx, tx, user.ID, posts, []rules.Rule{compiled})
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("rule %s added\n", rule.ID)
+	return nil
+}
+
+// handlerRulesRemove deletes one of the user's rules by ID.
+func handlerRulesRemove(ctx context.Context, s *state, cmd command, user database.User) error {
+	rawID := cmd.Args[0]
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid rule id %q", errUsage, rawID)
+	}
+
+	return inTx(ctx, s, func(tx *state) error {
+		deleted, err := tx.db.DeleteFilterRuleForUser(ctx, database.DeleteFilterRuleForUserParams{
+			ID:     id,
+			UserID: user.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return newError(errNotFound, "rule %s not found", rawID)
+		}
+		return revertRule(ctx, tx, user.ID, id)
+	})
+}
+
+// revertRule takes back what a deleted rule did to the user's posts. Each
+// state it set is cleared, then left to the user's other rules, which may
+// set it again; state the user set since is left alone.
+func revertRule(ctx context.Context, s *state, userID, ruleID uuid.UUID) error {
+	setBy := uuid.NullUUID{UUID: ruleID, Valid: true}
+	states, err := s.db.GetPostStatesSetByRule(ctx, setBy)
+	if err != nil {
+		return err
+	}
+	if len(states) == 0 {
+		return nil
+	}
+	if err := s.db.RevertRuleStates(ctx, database.RevertRuleStatesParams{
+		UpdatedAt: time.Now(),
+		RuleID:    setBy,
+	}); err != nil {
+		return err
+	}
+
+	userRules, err := loadRules(ctx, s, userID)
+	if err != nil {
+		return err
+	}
+	for _, postState := range states {
+		item := postItem(postState.FeedID, postState.Title, postState.Description, postState.Author, postState.Categories)
+		result := rules.Evaluate(userRules, item)
+		// Only redo the actions the deleted rule had taken.
+		if postState.ReadByRule != setBy {
+			result.MarkRead = uuid.NullUUID{}
+		}
+		if postState.StarredByRule != setBy {
+			result.Star = uuid.NullUUID{}
+		}
+		if postState.HiddenByRule != setBy {
+			result.Hide = uuid.NullUUID{}
+		}
+		if postState.HighlightedByRule != setBy {
+			result.Highlight = uuid.NullUUID{}
+		}
+		if err := recordRuleResult(ctx, s, userID, postState.PostID, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadRules fetches and compiles a user's rules, oldest first. Rules that no
+// longer compile are skipped rather than blocking ingestion.
+func loadRules(ctx context.Context, s *state, userID uuid.UUID) ([]rules.Rule, error) {
+	dbRules, err := s.db.GetFilterRulesForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled := make([]rules.Rule, 0, len(dbRules))
+	for _, r := range dbRules {
+		rule, err := rules.Compile(r.ID, r.FeedID, r.Field, r.MatchType, r.Pattern, r.Action)
+		if err != nil {
+			continue
+		}
+		compiled = append(compiled, rule)
+	}
+	return compiled, nil
+}
+
+// recordRuleResult stores the actions rules took on a post for the user,
+// with the rule behind each, so removing the rule can take it back. Rules
+// only fill in state that isn't set yet.
+func recordRuleResult(ctx context.Context, s *state, userID, postID uuid.UUID, result rules.Result) error {
+	if !result.Any() {
+		return nil
+	}
+
+	now := time.Now()
+	params := database.ApplyPostStateParams{
+		ID:                uuid.New(),
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		UserID:            userID,
+		PostID:            postID,
+		ReadByRule:        result.MarkRead,
+		StarredByRule:     result.Star,
+		HiddenByRule:      result.Hide,
+		HighlightedByRule: result.Highlight,
+	}
+	if result.MarkRead.Valid {
+		params.ReadAt = sql.NullTime{Time: now, Valid: true}
+	}
+	if result.Star.Valid {
+		params.StarredAt = sql.NullTime{Time: now, Valid: true}
+	}
+	return s.db.ApplyPostState(ctx, params)
+}
+
+// applyRulesToPosts runs rules against posts and records what they did for
+// the user.
+func applyRulesToPosts(ctx context.Context, s *state, userID uuid.UUID, posts []database.Post, userRules []rules.Rule) error {
+	if len(userRules) == 0 {
+		return nil
+	}
+	for _, post := range posts {
+		item := postItem(post.FeedID, post.Title, post.Description, post.Author, post.Categories)
+		if err := recordRuleResult(ctx, s, userID, post.ID, rules.Evaluate(userRules, item)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyRulesToNewPost runs each follower's rules against a freshly ingested post.
+func applyRulesToNewPost(ctx context.Context, s *state, post database.Post, cache map[uuid.UUID][]rules.Rule) error {
+	followerIDs, err := s.db.GetFollowerIDsForFeed(ctx, post.FeedID)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range followerIDs {
+		userRules, ok := cache[userID]
+		if !ok {
+			userRules, err = loadRules(ctx, s, userID)
+			if err != nil {
+				return err
+			}
+			cache[userID] = userRules
+		}
+		if err := applyRulesToPosts(ctx, s, userID, []database.Post{post}, userRules); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// postItem builds the rule view of a stored post.
+func postItem(feedID uuid.UUID, title string, description, author, categories sql.NullString) rules.Item {
+	return rules.Item{
+		FeedID:      feedID,
+		Title:       title,
+		Description: description.String,
+		Author:      author.String,
+		Categories:  splitCategories(categories),
+	}
+}
+
+// joinCategories stores item categories one per line.
+func joinCategories(categories []string) sql.NullString {
+	cleaned := make([]string, 0, len(categories))
+	for _, category := range categories {
+		if category = strings.TrimSpace(category); category != "" {
+			cleaned = append(cleaned, category)
+		}
+	}
+	return sql.NullString{String: strings.Join(cleaned, "\n"), Valid: len(cleaned) > 0}
+}
+
+// splitCategories reverses joinCategories.
+func splitCategories(categories sql.NullString) []string {
+	if !categories.Valid || categories.String == "" {
+		return nil
+	}
+	return strings.Split(categories.String, "\n")
+}
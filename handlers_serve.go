@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// shutdownGrace is how long serve lets requests in flight finish once it's
+// told to stop.
+const shutdownGrace = 5 * time.Second
+
+// newServer routes everything serve offers: the REST API, the Fever API,
+// and the web interface at every other path.
+func newServer(s *state) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(apiPrefix+"/", newAPI(s))
+	mux.Handle(feverPath, newFever(s))
+	mux.Handle("/", newWeb(s))
+	return mux
+}
+
+// handlerServe serves the APIs and the web interface until Ctrl-C or
+// --timeout, then lets the requests in flight finish.
+func handlerServe(ctx context.Context, s *state, cmd command) error {
+	listener, err := net.Listen("tcp", cmd.String("addr"))
+	if err != nil {
+		return newError(errUsage, "can't listen on %s: %v", cmd.String("addr"), err)
+	}
+	server := &http.Server{
+		Handler:           newServer(s),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(listener) }()
+	fmt.Printf("serving gator at http://%s (API under %s)\n", listener.Addr(), apiPrefix)
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownGrace)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	fmt.Println("stopped serving")
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/praneeth-ayla/gator/internal/database"
+)
+
+// handlerTag assigns one or more tags to the current user's follow of a feed.
+func handlerTag(ctx context.Context, s *state, cmd command, user database.User) error {
+	// Tags belong to the follow, so the user must follow the feed first.
+	follow, err := s.db.GetFeedFollowForUserByUrl(ctx, database.GetFeedFollowForUserByUrlParams{
+		UserID: user.ID,
+		Url:    cmd.Args[0],
+	})
+	if err != nil {
+		return notFound(err, "you are not following %s", cmd.Args[0])
+	}
+
+	tags := make([]string, 0, len(cmd.Args)-1)
+	for _, arg := range cmd.Args[1:] {
+		tag, err := normalizeTag(arg)
+		if err != nil {
+			return err
+		}
+		tags = append(tags, tag)
+	}
+
+	// Either every tag is added or none is.
+	err = inTx(ctx, s, func(tx *state) error {
+		for _, tag := range tags {
+			err := tx.db.AddFeedFollowTag(ctx, database.AddFeedFollowTagParams{
+				ID:           uuid.New(),
+				CreatedAt:    time.Now(),
+				UpdatedAt:    time.Now(),
+				FeedFollowID: follow.ID,
+				Name:         tag,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		fmt.Printf("tagged %s with %s\n", cmd.Args[0], tag)
+	}
+
+	return nil
+}
+
+// handlerUntag removes a tag from the current user's follow of a feed.
+func handlerUntag(ctx context.Context, s *state, cmd command, user database.User) error {
+	follow, err := s.db.GetFeedFollowForUserByUrl(ctx, database.GetFeedFollowForUserByUrlParams{
+		UserID: user.ID,
+		Url:    cmd.Args[0],
+	})
+	if err != nil {
+		return notFound(err, "you are not following %s", cmd.Args[0])
+	}
+
+	removed, err := s.db.RemoveFeedFollowTag(ctx, database.RemoveFeedFollowTagParams{
+		FeedFollowID: follow.ID,
+		Name:         strings.TrimSpace(cmd.Args[1]),
+	})
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return newError(errNotFound, "%s is not tagged with %s", cmd.Args[0], cmd.Args[1])
+	}
+
+	return nil
+}
+
+// handlerTags lists the current user's follows grouped by tag, with untagged
+// follows listed last.
+func handlerTags(ctx context.Context, s *state, cmd command, user database.User) error {
+	rows, err := s.db.GetTaggedFeedFollowsForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	views := make([]tagView, 0, len(rows))
+	for _, row := range rows {
+		views = append(views, tagView{
+			Tag:      row.TagName.String,
+			FeedName: row.FeedName,
+			FeedURL:  row.FeedUrl,
+		})
+	}
+
+	// Rows arrive ordered by tag, so a heading is printed whenever it changes.
+	heading, first := "", true
+	return render(cmd, views, func(row tagView) {
+		current := "(untagged)"
+		if row.Tag != "" {
+			current = row.Tag
+		}
+		if first || current != heading {
+			heading, first = current, false
+			fmt.Printf("%s:\n", heading)
+		}
+		fmt.Printf("  * %s (%s)\n", row.FeedName, row.FeedURL)
+	})
+}
+
+// handlerTagsRename renames a tag across all of the user's follows. Follows
+// that already carry the new name simply lose the old one.
+func handlerTagsRename(ctx context.Context, s *state, cmd command, user database.User) error {
+	oldName, err := normalizeTag(cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	newName, err := normalizeTag(cmd.Args[1])
+	if err != nil {
+		return err
+	}
+
+	err = inTx(ctx, s, func(tx *state) error {
+		renamed, err := tx.db.RenameTagForUser(ctx, database.RenameTagForUserParams{
+			NewName:   newName,
+			UpdatedAt: time.Now(),
+			OldName:   oldName,
+			UserID:    user.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Drop leftovers on follows that were already tagged with the new name.
+		merged, err := tx.db.DeleteTagForUser(ctx, database.DeleteTagForUserParams{
+			UserID: user.ID,
+			Name:   oldName,
+		})
+		if err != nil {
+			return err
+		}
+		if renamed+merged == 0 {
+			return newError(errNotFound, "tag %s not found", oldName)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("renamed tag %s to %s\n", oldName, newName)
+	return nil
+}
+
+// handlerTagsDelete removes a tag from all of the user's follows.
+func handlerTagsDelete(ctx context.Context, s *state, cmd command, user database.User) error {
+	name, err := normalizeTag(cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	deleted, err := s.db.DeleteTagForUser(ctx, database.DeleteTagForUserParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return newError(errNotFound, "tag %s not found", name)
+	}
+
+	fmt.Printf("deleted tag %s\n", name)
+	return nil
+}
+
+// normalizeTag trims a tag name and rejects empty ones.
+func normalizeTag(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("%w: tag name cannot be empty", errUsage)
+	}
+	return name, nil
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/praneeth-ayla/gator/internal/database"
+)
+
+// handlerUserRole makes a user an admin or a member. The last admin can't be
+// demoted, so someone can always manage the database.
+func handlerUserRole(ctx context.Context, s *state, cmd command, admin database.User) error {
+	name, role := cmd.Args[0], cmd.Args[1]
+	if role != roleAdmin && role != roleMember {
+		return newError(errUsage, "role must be %s or %s, not %q", roleAdmin, roleMember, role)
+	}
+
+	user, err := s.db.GetUser(ctx, name)
+	if err != nil {
+		return notFound(err, "no user named %q", name)
+	}
+	if user.Role == role {
+		fmt.Printf("%s's role is already %s\n", name, role)
+		return nil
+	}
+	if user.Role == roleAdmin {
+		admins, err := s.db.CountAdmins(ctx)
+		if err != nil {
+			return err
+		}
+		if admins <= 1 {
+			return newError(errUsage, "%s is the only admin: make someone else an admin first", name)
+		}
+	}
+
+	err = s.db.SetUserRole(ctx, database.SetUserRoleParams{
+		ID:        user.ID,
+		Role:      role,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s's role is now %s\n", name, role)
+	return nil
+}
+
+// handlerUserPasswd sets another user's password without knowing the old
+// one, e.g. for users who forgot theirs or never had one.
+func handlerUserPasswd(ctx context.Context, s *state, cmd command, admin database.User) error {
+	name := cmd.Args[0]
+	user, err := s.db.GetUser(ctx, name)
+	if err != nil {
+		return notFound(err, "no user named %q", name)
+	}
+	if err := confirm(cmd, "Replace the password of %s?", name); err != nil {
+		return err
+	}
+
+	hash, err := readNewPassword()
+	if err != nil {
+		return err
+	}
+	err = s.db.SetUserPassword(ctx, database.SetUserPasswordParams{
+		ID:           user.ID,
+		PasswordHash: hash,
+		UpdatedAt:    time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("password set for %s\n", name)
+	return nil
+}
+
+// handlerUserShow prints a user's details and how much they have stored.
+func handlerUserShow(ctx context.Context, s *state, cmd command, admin database.User) error {
+	name := cmd.Args[0]
+	user, err := s.db.GetUser(ctx, name)
+	if err != nil {
+		return notFound(err, "no user named %q", name)
+	}
+	view, err := newUserDetailView(ctx, s, user)
+	if err != nil {
+		return err
+	}
+	return render(cmd, []userDetailView{view}, func(user userDetailView) {
+		password := "not set"
+		if user.HasPassword {
+			password = "set"
+		}
+		fmt.Printf("Name:     %s\n", user.Name)
+		fmt.Printf("Role:     %s\n", user.Role)
+		fmt.Printf("Created:  %s\n", user.CreatedAt.Format(time.DateTime))
+		fmt.Printf("Password: %s\n", password)
+		fmt.Printf("Feeds:    %d owned, %d followed\n", user.FeedsOwned, user.Follows)
+		fmt.Printf("Posts:    %d read, %d starred\n", user.PostsRead, user.PostsStarred)
+		fmt.Printf("Rules:    %d\n", user.FilterRules)
+		fmt.Printf("Tokens:   %d\n", user.APITokens)
+	})
+}
+
+// newUserDetailView describes a user with counts of what they own.
+func newUserDetailView(ctx context.Context, s *state, user database.User) (userDetailView, error) {
+	stats, err := s.db.GetUserStats(ctx, user.ID)
+	if err != nil {
+		return userDetailView{}, err
+	}
+	return userDetailView{
+		Name:         user.Name,
+		Role:         user.Role,
+		CreatedAt:    user.CreatedAt,
+		HasPassword:  user.PasswordHash.Valid,
+		FeedsOwned:   stats.FeedsOwned,
+		Follows:      stats.Follows,
+		PostsRead:    stats.PostsRead,
+		PostsStarred: stats.PostsStarred,
+		FilterRules:  stats.FilterRules,
+		APITokens:    stats.ApiTokens,
+	}, nil
+}
+
+// handlerUserRename changes a user's name, following the rename in the
+// config file when it's the logged-in user.
+func handlerUserRename(ctx context.Context, s *state, cmd command, admin database.User) error {
+	name, newName := cmd.Args[0], cmd.Args[1]
+	user, err := s.db.GetUser(ctx, name)
+	if err != nil {
+		return notFound(err, "no user named %q", name)
+	}
+
+	err = s.db.RenameUser(ctx, database.RenameUserParams{
+		ID:        user.ID,
+		Name:      newName,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		return alreadyExists(err, "a user named %q already exists", newName)
+	}
+	if s.cfg.CurrentUserName == name {
+		if err := s.cfg.SetUser(newName); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("renamed %s to %s\n", name, newName)
+	return nil
+}
+
+// handlerUserRemove deletes a user after previewing what goes with them.
+// Deleting a user cascades to the feeds they added, so feeds someone else
+// follows are handed to that feed's longest-standing follower first, or
+// all of them to the user given with --to.
+func handlerUserRemove(ctx context.Context, s *state, cmd command, admin database.User) error {
+	name := cmd.Args[0]
+	user, err := s.db.GetUser(ctx, name)
+	if err != nil {
+		return notFound(err, "no user named %q", name)
+	}
+	if user.ID == admin.ID {
+		return newError(errUsage, "you can't remove yourself: ask another admin")
+	}
+
+	var heir *database.User
+	if to := cmd.String("to"); to != "" {
+		u, err := s.db.GetUser(ctx, to)
+		if err != nil {
+			return notFound(err, "no user named %q", to)
+		}
+		if u.ID == user.ID {
+			return newError(errUsage, "--to must name someone other than %s", name)
+		}
+		heir = &u
+	}
+
+	feeds, err := s.db.GetFeedsOwnedByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	// Work out who takes over each feed; feeds nobody takes are deleted.
+	owners := make([]*database.User, len(feeds))
+	for i, feed := range feeds {
+		if heir != nil {
+			owners[i] = heir
+			continue
+		}
+		if feed.OtherFollowers == 0 {
+			continue
+		}
+		owner, err := oldestOtherFollower(ctx, s, feed.ID, user.ID)
+		if err != nil {
+			return err
+		}
+		owners[i] = &owner
+	}
+
+	stats, err := s.db.GetUserStats(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removing %s also deletes their %d follows, %d filter rules, %d API tokens and read and starred marks.\n",
+		name, stats.Follows, stats.FilterRules, stats.ApiTokens)
+	if len(feeds) > 0 {
+		fmt.Printf("Feeds %s added:\n", name)
+	}
+	for i, feed := range feeds {
+		if owners[i] == nil {
+			fmt.Printf("  * %s (%s): deleted with its posts, no one else follows it\n", feed.Name, feed.Url)
+			continue
+		}
+		fmt.Printf("  * %s (%s): moves to %s\n", feed.Name, feed.Url, owners[i].Name)
+	}
+	if err := confirm(cmd, "Remove %s?", name); err != nil {
+		return err
+	}
+
+	// Hand feeds over and delete the user together, so a failure can't
+	// delete feeds that should have moved.
+	err = inTx(ctx, s, func(tx *state) error {
+		for i, feed := range feeds {
+			if owners[i] == nil {
+				continue
+			}
+			err := tx.db.SetFeedOwner(ctx, database.SetFeedOwnerParams{
+				ID:        feed.ID,
+				UserID:    owners[i].ID,
+				UpdatedAt: time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return tx.db.DeleteUser(ctx, user.ID)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("removed %s\n", name)
+	return nil
+}
+
+// oldestOtherFollower returns the user who has followed a feed the longest,
+// leaving out one user.
+func oldestOtherFollower(ctx context.Context, s *state, feedID, except uuid.UUID) (database.User, error) {
+	userID, err := s.db.GetOldestOtherFollower(ctx, database.GetOldestOtherFollowerParams{
+		FeedID: feedID,
+		UserID: except,
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+	return s.db.GetUserById(ctx, userID)
+}
@@ -1,16 +1,24 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"html"
 	"io"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/praneeth-ayla/gator/internal/config"
 	"github.com/praneeth-ayla/gator/internal/database"
+	"github.com/praneeth-ayla/gator/internal/rules"
+	"github.com/praneeth-ayla/gator/internal/storage"
 )
 
 // RSSFeed represents the structure of an RSS feed XML.
@@ -25,43 +33,63 @@ type RSSFeed struct {
 
 // RSSItem represents an individual item within an RSS feed.
 type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	PubDate     string   `xml:"pubDate"`
+	Author      string   `xml:"author"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string `xml:"category"`
 }
 
-// fetchFeed fetches and parses an RSS feed from a given URL.
-func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+// fetchFeed fetches and parses an RSS or Atom feed from a given URL.
+func fetchFeed(ctx context.Context, feedURL, userAgent string) (*RSSFeed, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("User-Agent", "gator")
+	req.Header.Set("User-Agent", userAgent)
 
 	client := http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", errNetwork, err)
 	}
 	defer resp.Body.Close()
 
 	// Check for HTTP errors.
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		return nil, fmt.Errorf("%w: unexpected status code %d", errNetwork, resp.StatusCode)
 	}
 
 	// Read the response body.
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", errNetwork, err)
 	}
 
-	var feed RSSFeed
-	// Unmarshal XML data into the RSSFeed struct.
-	err = xml.Unmarshal(data, &feed)
+	feed, err := parseFeed(data)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %s: %v", errParse, feedURL, err)
+	}
+	return feed, nil
+}
+
+// parseFeed parses an RSS or Atom document into an RSSFeed.
+func parseFeed(data []byte) (*RSSFeed, error) {
+	var feed RSSFeed
+	// Atom documents have a <feed> root and are converted to the RSS shape.
+	if rootElement(data) == "feed" {
+		var atom atomFeed
+		if err := xml.Unmarshal(data, &atom); err != nil {
+			return nil, err
+		}
+		feed = atom.toRSS()
+	} else {
+		// Unmarshal XML data into the RSSFeed struct.
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			return nil, err
+		}
 	}
 
 	// Unescape HTML entities in feed title and description.
@@ -69,7 +97,8 @@ func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	feed.Channel.Description = html.UnescapeString(feed.Channel.Description)
 
 	// Unescape HTML entities in each item's title and description.
-	for _, item := range feed.Channel.Item {
+	for i := range feed.Channel.Item {
+		item := &feed.Channel.Item[i]
 		item.Title = html.UnescapeString(item.Title)
 		item.Description = html.UnescapeString(item.Description)
 	}
@@ -77,51 +106,334 @@ func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	return &feed, nil
 }
 
+// atomFeed represents the parts of an Atom feed gator uses.
+type atomFeed struct {
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+// atomEntry represents a single entry within an Atom feed.
+type atomEntry struct {
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Summary   string     `xml:"summary"`
+	Content   string     `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Author    struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Categories []struct {
+		Term string `xml:"term,attr"`
+	} `xml:"category"`
+}
+
+// atomLink is an Atom link element; rel defaults to "alternate".
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+// toRSS converts an Atom feed into the RSSFeed shape used everywhere else.
+func (a atomFeed) toRSS() RSSFeed {
+	var feed RSSFeed
+	feed.Channel.Title = a.Title
+	feed.Channel.Link = alternateLink(a.Links)
+	feed.Channel.Description = a.Subtitle
+
+	for _, entry := range a.Entries {
+		item := RSSItem{
+			Title:       entry.Title,
+			Link:        alternateLink(entry.Links),
+			Description: entry.Summary,
+			PubDate:     entry.Published,
+			Author:      entry.Author.Name,
+		}
+		if item.Description == "" {
+			item.Description = entry.Content
+		}
+		if item.PubDate == "" {
+			item.PubDate = entry.Updated
+		}
+		for _, category := range entry.Categories {
+			item.Categories = append(item.Categories, category.Term)
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+	return feed
+}
+
+// alternateLink returns the link pointing at the human-readable page.
+func alternateLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// rootElement returns the local name of the document's root element.
+func rootElement(data []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
+
+// Where the current user comes from, as reported by whoami.
+const (
+	userFromFlag   = "--as flag"
+	userFromEnv    = "GATOR_USER"
+	userFromConfig = "config file"
+)
+
+// currentUser returns the name of the user commands act as and where it comes
+// from. An API token in GATOR_TOKEN wins. Otherwise a user given with --as,
+// on the command or when the shell was started, wins over GATOR_USER, which
+// wins over the one saved by login, so separate terminals can act as
+// different users without rewriting the config file. When auth is required,
+// only a token or a password login can pick the user.
+func currentUser(ctx context.Context, s *state, cmd command) (string, string, error) {
+	if token := os.Getenv(envToken); token != "" {
+		user, apiToken, err := tokenUser(ctx, s, token)
+		if err != nil {
+			return "", "", err
+		}
+		return user.Name, fmt.Sprintf("API token %q", apiToken.Name), nil
+	}
+
+	name, source := cmd.String("as"), userFromFlag
+	if name == "" && s.session != "" {
+		name = s.session
+	}
+	if name == "" {
+		value, from, _ := s.cfg.Get("current_user_name")
+		name, source = value, userFromConfig
+		if from == config.SourceEnv {
+			source = userFromEnv
+		}
+	}
+	if name == "" {
+		return "", "", nil
+	}
+	if s.cfg.Auth == config.AuthRequired && source != userFromConfig {
+		return "", "", newError(errAuth, "%s can't pick the user when auth is required: log in with a password, or set %s to an API token", source, envToken)
+	}
+	return name, source, nil
+}
+
 // middlewareLoggedIn is a middleware that ensures a user is logged in before executing the handler.
 func middlewareLoggedIn(
-	handler func(s *state, cmd command, user database.User) error,
-) func(*state, command) error {
+	handler func(ctx context.Context, s *state, cmd command, user database.User) error,
+) func(context.Context, *state, command) error {
 
-	return func(s *state, cmd command) error {
-		// Attempt to retrieve the current user from the database.
-		user, err := s.db.GetUser(context.Background(), s.cfg.CurrentUserName)
+	return func(ctx context.Context, s *state, cmd command) error {
+		user, _, err := loggedInUser(ctx, s, cmd)
 		if err != nil {
-			return err // Return error if user is not found or other DB error.
+			return err
 		}
+		return handler(ctx, s, cmd, user)
+	}
+}
 
-		// Execute the original handler with the retrieved user.
-		return handler(s, cmd, user)
+// loggedInUser returns the user commands act as, as currentUser picks them,
+// and where that comes from. A user with a password can only be picked with
+// their API token or with the session a password login saved in the
+// config, whatever the auth setting, since the config is just a file.
+func loggedInUser(ctx context.Context, s *state, cmd command) (database.User, string, error) {
+	name, source, err := currentUser(ctx, s, cmd)
+	if err != nil {
+		return database.User{}, "", err
+	}
+	if name == "" {
+		return database.User{}, "", newError(errNotLoggedIn, "not logged in, run \"gator login <name>\" or \"gator register <name>\" first")
+	}
+	user, err := s.db.GetUser(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, "", newError(errNotLoggedIn, "acting as %q from %s, but that user doesn't exist", name, source)
 	}
+	if err != nil {
+		return user, "", err
+	}
+
+	if err := checkPicked(ctx, s, user); err != nil {
+		return database.User{}, "", err
+	}
+	return user, source, nil
+}
+
+// checkPicked refuses to act as a user with a password unless an API token
+// or a login session proves the caller is them.
+func checkPicked(ctx context.Context, s *state, user database.User) error {
+	if !user.PasswordHash.Valid || os.Getenv(envToken) != "" {
+		return nil
+	}
+	ok, err := hasLoginSession(ctx, s, user)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return newError(errAuth, "%q has a password: log in with \"gator login %s\", or set %s to one of their API tokens", user.Name, user.Name, envToken)
+	}
+	return nil
 }
 
-// scrapeFeeds fetches the next feed to scrape, marks it as fetched, and processes its items.
-func scrapeFeeds(s *state) error {
-	ctx := context.Background()
+// Roles a user can have. Admins can run the commands that affect other users.
+const (
+	roleAdmin  = "admin"
+	roleMember = "member"
+)
+
+// middlewareAdmin is like middlewareLoggedIn, but also requires the user to
+// be an admin.
+func middlewareAdmin(
+	handler func(ctx context.Context, s *state, cmd command, user database.User) error,
+) func(context.Context, *state, command) error {
+	return middlewareLoggedIn(func(ctx context.Context, s *state, cmd command, user database.User) error {
+		if user.Role != roleAdmin {
+			return newError(errPermission, "%q isn't an admin: only admins can run \"%s\"", user.Name, cmd.Name)
+		}
+		return handler(ctx, s, cmd, user)
+	})
+}
+
+// inTx runs fn with a copy of s whose queries share one transaction, so
+// operations that write several rows happen entirely or not at all.
+func inTx(ctx context.Context, s *state, fn func(tx *state) error) error {
+	return s.db.InTx(ctx, func(db storage.Store) error {
+		tx := *s
+		tx.db = db
+		return fn(&tx)
+	})
+}
+
+// scrapeFeeds scrapes the feed that was fetched longest ago.
+// The returned record describes the feed that was scraped, even when fetching it failed.
+func scrapeFeeds(ctx context.Context, s *state) (scrapeView, error) {
 	// Get the next feed that needs to be fetched.
 	feedToFetch, err := s.db.GetNextFeedToFetch(ctx)
 	if err != nil {
-		return err
+		return scrapeView{}, err
 	}
+	return scrapeFeed(ctx, s, feedToFetch)
+}
 
-	// Mark the feed as fetched in the database.
-	err = s.db.MarkFeedFetched(ctx, database.MarkFeedFetchedParams{
+// scrapeFeed fetches one feed, then saves its new items as posts and marks
+// it as fetched in one transaction. A feed that fails to fetch or save is
+// marked too, so one broken feed doesn't hold up the others.
+func scrapeFeed(ctx context.Context, s *state, feedToFetch database.Feed) (scrapeView, error) {
+	result := scrapeView{Feed: feedToFetch.Name, URL: feedToFetch.Url}
+	markFetched := database.MarkFeedFetchedParams{
 		ID:            feedToFetch.ID,
 		UpdatedAt:     time.Now(),
 		LastFetchedAt: sql.NullTime{Time: time.Now(), Valid: true},
-	})
+	}
+
+	// Fetch the content of the feed URL, giving up after the configured timeout.
+	fetchCtx, cancel := context.WithTimeout(ctx, s.cfg.FetchTimeout)
+	defer cancel()
+	feed, err := fetchFeed(fetchCtx, feedToFetch.Url, s.cfg.UserAgent)
 	if err != nil {
-		return err
+		if markErr := s.db.MarkFeedFetched(ctx, markFetched); markErr != nil {
+			return result, markErr
+		}
+		return result, err
 	}
 
-	// Fetch the content of the feed URL.
-	feed, err := fetchFeed(ctx, feedToFetch.Url)
+	var saved int
+	err = inTx(ctx, s, func(tx *state) error {
+		saved, err = savePosts(ctx, tx, feedToFetch, feed)
+		if err != nil {
+			return err
+		}
+		return tx.db.MarkFeedFetched(ctx, markFetched)
+	})
 	if err != nil {
-		return err
+		// The transaction took the mark with it, so mark the feed again.
+		if markErr := s.db.MarkFeedFetched(ctx, markFetched); markErr != nil {
+			return result, markErr
+		}
+		return result, err
 	}
-	// Print titles and descriptions of feed items.
+	result.NewPosts = saved
+	return result, nil
+}
+
+// savePosts saves each feed item as a post, skipping ones already stored,
+// and runs followers' filter rules against the new ones. It returns how many
+// posts were new. It must run in a transaction, which holds post numbering
+// until it ends.
+func savePosts(ctx context.Context, s *state, feedToFetch database.Feed, feed *RSSFeed) (int, error) {
+	if err := s.db.LockPostNumbers(ctx); err != nil {
+		return 0, err
+	}
+
+	saved := 0
+	ruleCache := map[uuid.UUID][]rules.Rule{}
 	for _, item := range feed.Channel.Item {
-		fmt.Printf("Title: %v\n", item.Title)
-		fmt.Printf("Description: %v\n", item.Description)
+		publishedAt := sql.NullTime{}
+		if t, err := parsePubDate(item.PubDate); err == nil {
+			publishedAt = sql.NullTime{Time: t, Valid: true}
+		}
+
+		// Many feeds put the author in dc:creator rather than author.
+		author := item.Author
+		if author == "" {
+			author = item.Creator
+		}
+
+		post, err := s.db.CreatePost(ctx, database.CreatePostParams{
+			ID:          uuid.New(),
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+			Title:       item.Title,
+			Url:         item.Link,
+			Description: sql.NullString{String: item.Description, Valid: item.Description != ""},
+			PublishedAt: publishedAt,
+			FeedID:      feedToFetch.ID,
+			Author:      sql.NullString{String: author, Valid: author != ""},
+			Categories:  joinCategories(item.Categories),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("couldn't save post %q: %w", item.Link, err)
+		}
+		saved++
+
+		if err := applyRulesToNewPost(ctx, s, post, ruleCache); err != nil {
+			return 0, fmt.Errorf("couldn't apply rules to post %q: %w", item.Link, err)
+		}
 	}
-	return nil
+	return saved, nil
+}
+
+// pubDateLayouts lists the date formats seen in the wild for RSS pubDate values.
+var pubDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+}
+
+// parsePubDate parses an RSS pubDate using the first layout that matches.
+func parsePubDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range pubDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
 }
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPasswords(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$") || strings.Contains(hash, "correct horse") {
+		t.Errorf("HashPassword = %q", hash)
+	}
+	if err := CheckPassword(hash, "correct horse"); err != nil {
+		t.Errorf("CheckPassword with the right password: %v", err)
+	}
+	if err := CheckPassword(hash, "battery staple"); !errors.Is(err, ErrMismatch) {
+		t.Errorf("CheckPassword with the wrong password = %v, want ErrMismatch", err)
+	}
+
+	// The same password hashes differently each time.
+	if again, _ := HashPassword("correct horse"); again == hash {
+		t.Error("HashPassword reused a salt")
+	}
+	if _, err := HashPassword("short"); err == nil {
+		t.Error("HashPassword accepted a short password")
+	}
+	if err := CheckPassword("$2a$10$bcrypt", "correct horse"); err == nil || errors.Is(err, ErrMismatch) {
+		t.Errorf("CheckPassword with an unknown hash = %v", err)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	token, hash, err := NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, tokenPrefix) || hash != HashToken(token) || strings.Contains(hash, token) {
+		t.Errorf("NewToken = %q, %q", token, hash)
+	}
+	if other, _, _ := NewToken(); other == token {
+		t.Error("NewToken returned the same token twice")
+	}
+}
+
+func TestFeverKey(t *testing.T) {
+	if got := FeverKey("alice", "correct horse"); got != "23faea553e61d105a779b78a1f289030" {
+		t.Errorf("FeverKey = %q", got)
+	}
+}
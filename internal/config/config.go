@@ -1,73 +1,436 @@
 package config
 
 import (
+	"bytes"
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"io/fs"
 	"os"
-	"path"
+	"path/filepath"
+	"time"
 )
 
-// Config holds application configuration settings.
+// Environment variables that override values from the config file.
+const (
+	EnvDbURL   = "GATOR_DB_URL"
+	EnvUser    = "GATOR_USER"
+	EnvProfile = "GATOR_PROFILE"
+)
+
+// DefaultProfile names the profile made of the top-level settings in the
+// config file.
+const DefaultProfile = "default"
+
+// Keys of the config file that hold profiles rather than settings.
+const (
+	keyProfile  = "profile"
+	keyProfiles = "profiles"
+)
+
+// ErrNoDbURL is returned by Load when neither the config file nor the
+// environment supplies a database URL.
+var ErrNoDbURL = errors.New("no database URL configured")
+
+// legacyFileName is the config file used before XDG paths were supported,
+// relative to the home directory.
+const legacyFileName = ".gatorconfig.json"
+
+// Config holds application configuration settings: the values from the
+// config file with defaults and environment overrides applied.
 type Config struct {
-	DbURL           string `json:"db_url"`
-	CurrentUserName string `json:"current_user_name"`
+	DbURL           string
+	CurrentUserName string
+	SessionToken    string
+	FetchTimeout    time.Duration
+	Concurrency     int
+	UserAgent       string
+	Output          string
+	Color           string
+	Auth            string
+
+	// Profile is the profile in use for this run.
+	Profile string
+
+	// path is the file the config is read from and written back to.
+	path string
+	// values holds the settings stored in the file itself, without
+	// defaults or environment overrides, so saving never writes those.
+	values map[string]string
+	// savedProfile is the profile the file selects, which Profile
+	// overrides for a single run.
+	savedProfile string
+	// profiles holds the per-profile settings of each named profile.
+	profiles map[string]map[string]string
 }
 
-// Read reads the application configuration from a JSON file.
-func Read() (Config, error) {
-	config := Config{}
+// Path returns the file the config was read from.
+func (cnf *Config) Path() string {
+	return cnf.path
+}
 
-	// Get the path to the configuration file.
-	filePath, err := getConfigFilePath()
+// DefaultPath returns the config file to use when none is given:
+// $XDG_CONFIG_HOME/gator/config.json (~/.config/gator/config.json when
+// XDG_CONFIG_HOME is unset), or the legacy ~/.gatorconfig.json when only
+// that one exists.
+func DefaultPath() (string, error) {
+	configDir, err := os.UserConfigDir()
 	if err != nil {
-		return config, fmt.Errorf("unable to get home dir")
+		return "", fmt.Errorf("unable to find config dir: %w", err)
 	}
-	// Read the file content.
-	fileData, err := os.ReadFile(filePath)
-	if err != nil {
-		return config, fmt.Errorf("unable to read file")
+	path := filepath.Join(configDir, "gator", "config.json")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
 	}
 
-	// Unmarshal JSON data into the Config struct.
-	json.Unmarshal(fileData, &config)
+	if home, err := os.UserHomeDir(); err == nil {
+		legacy := filepath.Join(home, legacyFileName)
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy, nil
+		}
+	}
+	return path, nil
+}
+
+// Read reads the application configuration from the default path, using the
+// profile the file selects.
+func Read() (Config, error) {
+	return Load("", "")
+}
+
+// Load reads the application configuration from a JSON file, or from the
+// default path when path is empty, then applies defaults and environment
+// overrides and validates the result. A missing file is only an error when
+// the environment doesn't supply the database URL either; when the database
+// URL is all that's missing, the error wraps ErrNoDbURL and the returned
+// config is otherwise usable.
+//
+// The profile used is the one given, else the one named by GATOR_PROFILE,
+// else the one the file selects.
+func Load(path, profile string) (Config, error) {
+	if path == "" {
+		defaultPath, err := DefaultPath()
+		if err != nil {
+			return Config{}, err
+		}
+		path = defaultPath
+	}
+	config := Config{path: path, values: map[string]string{}, profiles: map[string]map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	missing := errors.Is(err, fs.ErrNotExist)
+	switch {
+	case missing:
+	case err != nil:
+		return config, fmt.Errorf("unable to read config file: %w", err)
+	default:
+		if err := decode(data, &config); err != nil {
+			return config, fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+
+	config.Profile = cmp.Or(profile, os.Getenv(EnvProfile), config.savedProfile, DefaultProfile)
+	if !config.hasProfile(config.Profile) {
+		return config, fmt.Errorf("config file %s: no profile named %q", path, config.Profile)
+	}
 
+	if err := config.resolve(); err != nil {
+		return config, fmt.Errorf("config file %s: %w", path, err)
+	}
+	if config.DbURL == "" {
+		if missing {
+			return config, fmt.Errorf("config file %s not found: %w: create it with a db_url, or set %s", path, ErrNoDbURL, EnvDbURL)
+		}
+		if config.Profile != DefaultProfile {
+			return config, fmt.Errorf("config file %s: %w: set db_url for profile %q", path, ErrNoDbURL, config.Profile)
+		}
+		return config, fmt.Errorf("config file %s: %w: set db_url to a postgres:// or sqlite:// URL, or set %s", path, ErrNoDbURL, EnvDbURL)
+	}
 	return config, nil
 }
 
-// SetUser sets the current user name in the configuration and persists it to disk.
-func (cnf *Config) SetUser(userName string) error {
-	cnf.CurrentUserName = userName
+// resolve computes the effective settings from defaults, the file values and
+// the environment, validating each one.
+func (cnf *Config) resolve() error {
+	for _, setting := range Settings {
+		value, _ := cnf.lookup(setting)
+		if err := setting.apply(cnf, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Source says where the effective value of a setting comes from.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+)
+
+// lookup returns the effective raw value of a setting and its source.
+func (cnf *Config) lookup(setting Setting) (string, Source) {
+	if setting.Env != "" {
+		if value := os.Getenv(setting.Env); value != "" {
+			return value, SourceEnv
+		}
+	}
+	if value, ok := cnf.scope(setting)[setting.Key]; ok {
+		return value, SourceFile
+	}
+	return setting.Default, SourceDefault
+}
 
-	// Get the path to the configuration file.
-	filePath, err := getConfigFilePath()
+// scope returns the file values a setting is read from and written to: the
+// active profile's for per-profile settings, the top-level ones otherwise.
+func (cnf *Config) scope(setting Setting) map[string]string {
+	if setting.PerProfile && cnf.Profile != "" && cnf.Profile != DefaultProfile {
+		return cnf.profiles[cnf.Profile]
+	}
+	if cnf.values == nil {
+		cnf.values = map[string]string{}
+	}
+	return cnf.values
+}
+
+// Get returns the effective value of a setting and where it comes from.
+// Secret values are masked.
+func (cnf *Config) Get(key string) (string, Source, error) {
+	setting, err := Lookup(key)
 	if err != nil {
-		return fmt.Errorf("unable to get config path")
+		return "", "", err
+	}
+	value, source := cnf.lookup(setting)
+	if setting.Secret {
+		value = setting.mask(value)
 	}
+	return value, source, nil
+}
 
-	// Marshal the updated configuration to JSON.
-	data, err := json.Marshal(cnf)
+// Validate checks that a setting exists and can be set to value.
+func Validate(key, value string) error {
+	setting, err := Lookup(key)
 	if err != nil {
-		return fmt.Errorf("unable to marshal data")
+		return err
+	}
+	if setting.ReadOnly != "" {
+		return fmt.Errorf("%s can't be set directly: %s", key, setting.ReadOnly)
+	}
+	if setting.Required && value == "" {
+		return fmt.Errorf("%s is required and can't be empty", key)
 	}
-	// Write the updated configuration back to the file.
-	err = os.WriteFile(filePath, data, 0644)
+	return setting.apply(&Config{}, value)
+}
+
+// Set validates a value, stores it in the config file and applies it.
+func (cnf *Config) Set(key, value string) error {
+	if err := Validate(key, value); err != nil {
+		return err
+	}
+	setting, _ := Lookup(key)
+	cnf.scope(setting)[key] = value
+	if err := cnf.resolve(); err != nil {
+		return err
+	}
+	return cnf.save()
+}
+
+// Unset removes a setting from the config file so its default applies again.
+func (cnf *Config) Unset(key string) error {
+	setting, err := Lookup(key)
 	if err != nil {
-		return fmt.Errorf("unable to update file data")
+		return err
 	}
+	if setting.Required {
+		return fmt.Errorf("%s is required and can't be unset", key)
+	}
+	delete(cnf.scope(setting), key)
+	if err := cnf.resolve(); err != nil {
+		return err
+	}
+	return cnf.save()
+}
 
+// decode parses the config file strictly into cnf, reporting where syntax
+// errors are and rejecting unknown keys and values of the wrong type.
+func decode(data []byte, cnf *Config) error {
+	raw := map[string]json.RawMessage{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	err := decoder.Decode(&raw)
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+	case errors.Is(err, io.EOF):
+		return errors.New("file is empty")
+	case errors.As(err, &syntaxErr):
+		line, column := position(data, syntaxErr.Offset)
+		return fmt.Errorf("invalid JSON at line %d, column %d: %v", line, column, syntaxErr)
+	case errors.As(err, &typeErr):
+		return fmt.Errorf("the config must be a JSON object, not a %s", typeErr.Value)
+	default:
+		return err
+	}
+	if decoder.More() {
+		return errors.New("unexpected data after the config object")
+	}
+
+	if message, ok := raw[keyProfiles]; ok {
+		delete(raw, keyProfiles)
+		profiles := map[string]map[string]json.RawMessage{}
+		if err := json.Unmarshal(message, &profiles); err != nil {
+			return fmt.Errorf("%s must map profile names to objects of settings", keyProfiles)
+		}
+		for name, profile := range profiles {
+			if err := validateProfileName(name); err != nil {
+				return fmt.Errorf("%s: %w", keyProfiles, err)
+			}
+			values, err := decodeValues(profile, true)
+			if err != nil {
+				return fmt.Errorf("profile %q: %w", name, err)
+			}
+			cnf.profiles[name] = values
+		}
+	}
+	if message, ok := raw[keyProfile]; ok {
+		delete(raw, keyProfile)
+		if err := json.Unmarshal(message, &cnf.savedProfile); err != nil {
+			return fmt.Errorf("%s must be a string, not %s", keyProfile, message)
+		}
+		if !cnf.hasProfile(cnf.savedProfile) {
+			return fmt.Errorf("%s names %q, which isn't defined in %s", keyProfile, cnf.savedProfile, keyProfiles)
+		}
+	}
+
+	values, err := decodeValues(raw, false)
+	if err != nil {
+		return err
+	}
+	cnf.values = values
 	return nil
+}
 
+// decodeValues converts the settings of one object in the config file into
+// their raw string form. Profiles may only hold per-profile settings.
+func decodeValues(raw map[string]json.RawMessage, profile bool) (map[string]string, error) {
+	values := make(map[string]string, len(raw))
+	for key, message := range raw {
+		setting, err := Lookup(key)
+		if err != nil {
+			return nil, err
+		}
+		if profile && !setting.PerProfile {
+			return nil, fmt.Errorf("%s can't be set per profile", key)
+		}
+		value, err := setting.kind.decode(message)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a %s, not %s", key, setting.kind, message)
+		}
+		values[key] = value
+	}
+	return values, nil
 }
 
-// getConfigFilePath returns the full path to the application's configuration file.
-func getConfigFilePath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+// position converts the offset of a JSON syntax error, which points just
+// past the offending byte, into a 1-based line and column.
+func position(data []byte, offset int64) (int, int) {
+	before := data[:min(max(int(offset)-1, 0), len(data))]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// SetUser sets the current user name of the active profile and persists it
+// to disk.
+func (cnf *Config) SetUser(userName string) error {
+	setting, _ := Lookup(keyCurrentUser)
+	cnf.CurrentUserName = userName
+	cnf.scope(setting)[keyCurrentUser] = userName
+	return cnf.save()
+}
+
+// SetLogin sets the current user along with the token of the session a
+// password login started, or no token for a login without one, and writes
+// the config file.
+func (cnf *Config) SetLogin(userName, sessionToken string) error {
+	setting, _ := Lookup(keySessionToken)
+	cnf.SessionToken = sessionToken
+	if sessionToken == "" {
+		delete(cnf.scope(setting), keySessionToken)
+	} else {
+		cnf.scope(setting)[keySessionToken] = sessionToken
+	}
+	return cnf.SetUser(userName)
+}
+
+// save writes the file values back atomically: a temporary file in the same
+// directory is written, synced and renamed over the config, so an
+// interrupted write never leaves a truncated file behind.
+func (cnf *Config) save() error {
+	if cnf.path == "" {
+		path, err := DefaultPath()
+		if err != nil {
+			return err
+		}
+		cnf.path = path
+	}
+
+	file := encodeValues(cnf.values)
+	if cnf.savedProfile != "" && cnf.savedProfile != DefaultProfile {
+		file[keyProfile], _ = json.Marshal(cnf.savedProfile)
+	}
+	if len(cnf.profiles) > 0 {
+		profiles := make(map[string]map[string]json.RawMessage, len(cnf.profiles))
+		for name, values := range cnf.profiles {
+			profiles[name] = encodeValues(values)
+		}
+		file[keyProfiles], _ = json.Marshal(profiles)
+	}
+	// Map keys are marshaled in sorted order, so the file stays stable.
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal config: %w", err)
+	}
+
+	dir := filepath.Dir(cnf.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("unable to create config dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".config-*.json")
 	if err != nil {
-		log.Fatal(err)
-		return "", err
+		return fmt.Errorf("unable to update config file: %w", err)
 	}
-	filePath := path.Join(homeDir, ".gatorconfig.json")
-	return filePath, nil
+	defer os.Remove(tmp.Name())
 
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to update config file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to update config file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to update config file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), cnf.path); err != nil {
+		return fmt.Errorf("unable to update config file: %w", err)
+	}
+	return nil
+}
+
+// encodeValues converts raw values into their form in the config file.
+func encodeValues(values map[string]string) map[string]json.RawMessage {
+	file := make(map[string]json.RawMessage, len(values))
+	for key, value := range values {
+		if setting, err := Lookup(key); err == nil {
+			file[key] = setting.kind.encode(value)
+		}
+	}
+	return file
 }
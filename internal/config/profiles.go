@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// profileNamePattern matches the names profiles can be given.
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// validateProfileName checks that name can be used for a new profile.
+func validateProfileName(name string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("%q is the name of the top-level settings", DefaultProfile)
+	}
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, - and _", name)
+	}
+	return nil
+}
+
+// Profile describes one profile of the config file.
+type Profile struct {
+	Name string
+	// DbURL is masked so it can be printed.
+	DbURL           string
+	CurrentUserName string
+	// Active is set for the profile in use for this run.
+	Active bool
+	// Saved is set for the profile the file selects.
+	Saved bool
+}
+
+// hasProfile reports whether name is the default profile or a named one.
+func (cnf *Config) hasProfile(name string) bool {
+	_, ok := cnf.profiles[name]
+	return ok || name == DefaultProfile
+}
+
+// savedOrDefault returns the profile the file selects.
+func (cnf *Config) savedOrDefault() string {
+	if cnf.savedProfile == "" {
+		return DefaultProfile
+	}
+	return cnf.savedProfile
+}
+
+// Profiles lists the default profile followed by the named ones by name.
+// Values are read from the file, without environment overrides.
+func (cnf *Config) Profiles() []Profile {
+	names := make([]string, 0, len(cnf.profiles))
+	for name := range cnf.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	profiles := []Profile{cnf.profile(DefaultProfile, cnf.values)}
+	for _, name := range names {
+		profiles = append(profiles, cnf.profile(name, cnf.profiles[name]))
+	}
+	return profiles
+}
+
+// profile describes the profile with the given name and file values.
+func (cnf *Config) profile(name string, values map[string]string) Profile {
+	return Profile{
+		Name:            name,
+		DbURL:           maskURL(values[keyDbURL]),
+		CurrentUserName: values[keyCurrentUser],
+		Active:          name == cnf.Profile,
+		Saved:           name == cnf.savedOrDefault(),
+	}
+}
+
+// UseProfile makes the file select a profile for later runs.
+func (cnf *Config) UseProfile(name string) error {
+	if !cnf.hasProfile(name) {
+		return fmt.Errorf("no profile named %q", name)
+	}
+	cnf.savedProfile = name
+	return cnf.save()
+}
+
+// AddProfile creates a profile with its own database URL.
+func (cnf *Config) AddProfile(name, dbURL string) error {
+	if err := validateProfileName(name); err != nil {
+		return err
+	}
+	if cnf.hasProfile(name) {
+		return fmt.Errorf("a profile named %q already exists", name)
+	}
+	if err := Validate(keyDbURL, dbURL); err != nil {
+		return err
+	}
+	if cnf.profiles == nil {
+		cnf.profiles = map[string]map[string]string{}
+	}
+	cnf.profiles[name] = map[string]string{keyDbURL: dbURL}
+	return cnf.save()
+}
+
+// RemoveProfile deletes a named profile. The profile the file selects can't
+// be removed until another one is selected.
+func (cnf *Config) RemoveProfile(name string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("the %s profile can't be removed", DefaultProfile)
+	}
+	if !cnf.hasProfile(name) {
+		return fmt.Errorf("no profile named %q", name)
+	}
+	if name == cnf.savedOrDefault() {
+		return fmt.Errorf("profile %q is in use: switch to another one first", name)
+	}
+	delete(cnf.profiles, name)
+	return cnf.save()
+}
@@ -0,0 +1,284 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/praneeth-ayla/gator/internal/output"
+)
+
+// Keys of the settings in the config file.
+const (
+	keyDbURL        = "db_url"
+	keyCurrentUser  = "current_user_name"
+	keySessionToken = "session_token"
+	keyFetchTimeout = "fetch_timeout"
+	keyConcurrency  = "concurrency"
+	keyUserAgent    = "user_agent"
+	keyOutput       = "output"
+	keyColor        = "color"
+	keyAuth         = "auth"
+)
+
+// Values accepted by the color setting.
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// Values accepted by the auth setting.
+const (
+	AuthOptional = "optional"
+	AuthRequired = "required"
+)
+
+// Setting describes one known config setting.
+type Setting struct {
+	Key         string
+	Description string
+	// Default is the raw value used when neither the file nor the
+	// environment sets one.
+	Default string
+	// Env names the environment variable that overrides the file, if any.
+	Env string
+	// Secret settings are masked when printed, with mask.
+	Secret bool
+	// Required settings can't be unset.
+	Required bool
+	// PerProfile settings are stored separately for each profile.
+	PerProfile bool
+	// ReadOnly, when set, explains how the setting is changed instead of
+	// config set.
+	ReadOnly string
+
+	kind kind
+	// mask hides a secret value.
+	mask func(value string) string
+	// parse validates a raw value and stores it in the config.
+	parse func(cnf *Config, value string) error
+}
+
+// apply validates a raw value and stores it in cnf.
+func (setting Setting) apply(cnf *Config, value string) error {
+	if err := setting.parse(cnf, value); err != nil {
+		return fmt.Errorf("%s: %w", setting.Key, err)
+	}
+	return nil
+}
+
+// Settings lists every known setting in the order they are listed.
+var Settings = []Setting{
+	{
+		Key:         keyDbURL,
+		Description: "database URL: postgres://... or sqlite://...",
+		Env:         EnvDbURL,
+		Secret:      true,
+		Required:    true,
+		PerProfile:  true,
+		kind:        kindString,
+		mask:        maskURL,
+		parse: func(cnf *Config, value string) error {
+			// An empty URL is reported once every setting is resolved, so
+			// a missing file and an empty value read the same.
+			if value != "" {
+				parsed, err := url.Parse(value)
+				if err != nil || parsed.Scheme == "" {
+					return fmt.Errorf("%q is not a URL: use postgres://... or sqlite://...", maskURL(value))
+				}
+			}
+			cnf.DbURL = value
+			return nil
+		},
+	},
+	{
+		Key:         keyCurrentUser,
+		Description: "user commands run as",
+		Env:         EnvUser,
+		ReadOnly:    `use "gator login" or "gator register"`,
+		PerProfile:  true,
+		kind:        kindString,
+		parse: func(cnf *Config, value string) error {
+			cnf.CurrentUserName = value
+			return nil
+		},
+	},
+	{
+		Key:         keySessionToken,
+		Description: "proves a password login for the current user",
+		ReadOnly:    `use "gator login"`,
+		Secret:      true,
+		PerProfile:  true,
+		kind:        kindString,
+		mask:        maskToken,
+		parse: func(cnf *Config, value string) error {
+			cnf.SessionToken = value
+			return nil
+		},
+	},
+	{
+		Key:         keyFetchTimeout,
+		Description: "how long to wait for a feed to download, e.g. 30s",
+		Default:     "30s",
+		kind:        kindDuration,
+		parse: func(cnf *Config, value string) error {
+			timeout, err := time.ParseDuration(value)
+			if err != nil || timeout <= 0 {
+				return fmt.Errorf("%q is not a positive duration such as 30s or 1m", value)
+			}
+			cnf.FetchTimeout = timeout
+			return nil
+		},
+	},
+	{
+		Key:         keyConcurrency,
+		Description: "how many feeds agg once fetches at a time",
+		Default:     "4",
+		kind:        kindInt,
+		parse: func(cnf *Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 64 {
+				return fmt.Errorf("%q is not a number from 1 to 64", value)
+			}
+			cnf.Concurrency = n
+			return nil
+		},
+	},
+	{
+		Key:         keyUserAgent,
+		Description: "User-Agent header sent when fetching feeds",
+		Default:     "gator",
+		kind:        kindString,
+		parse: func(cnf *Config, value string) error {
+			if value == "" {
+				return errors.New("must not be empty")
+			}
+			cnf.UserAgent = value
+			return nil
+		},
+	},
+	{
+		Key:         keyOutput,
+		Description: "default for --output: text, json, ndjson, csv, table or template=<go template>",
+		Default:     output.Text,
+		kind:        kindString,
+		parse: func(cnf *Config, value string) error {
+			if _, err := output.Parse(value); err != nil {
+				return err
+			}
+			cnf.Output = value
+			return nil
+		},
+	},
+	{
+		Key:         keyColor,
+		Description: "color in text output: auto, always or never",
+		Default:     ColorAuto,
+		kind:        kindString,
+		parse: func(cnf *Config, value string) error {
+			switch value {
+			case ColorAuto, ColorAlways, ColorNever:
+			default:
+				return fmt.Errorf("%q is not one of auto, always or never", value)
+			}
+			cnf.Color = value
+			return nil
+		},
+	},
+	{
+		Key:         keyAuth,
+		Description: "passwords: optional keeps password-less users working, required makes every login use one",
+		Default:     AuthOptional,
+		PerProfile:  true,
+		kind:        kindString,
+		parse: func(cnf *Config, value string) error {
+			if value != AuthOptional && value != AuthRequired {
+				return fmt.Errorf("%q is not one of optional or required", value)
+			}
+			cnf.Auth = value
+			return nil
+		},
+	},
+}
+
+// Lookup returns the setting with the given key.
+func Lookup(key string) (Setting, error) {
+	for _, setting := range Settings {
+		if setting.Key == key {
+			return setting, nil
+		}
+	}
+	return Setting{}, fmt.Errorf("unknown setting %q", key)
+}
+
+// kind is the JSON type a setting is stored as in the config file. Values
+// are handled as strings everywhere else.
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindDuration
+)
+
+func (k kind) String() string {
+	if k == kindInt {
+		return "number"
+	}
+	return "string"
+}
+
+// decode converts a value from the config file into its raw string form.
+func (k kind) decode(message json.RawMessage) (string, error) {
+	if k == kindInt {
+		var n int
+		if err := json.Unmarshal(message, &n); err != nil {
+			return "", err
+		}
+		return strconv.Itoa(n), nil
+	}
+	var s string
+	if err := json.Unmarshal(message, &s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// encode converts a raw value into its form in the config file. Values are
+// validated before they are stored, so they always convert.
+func (k kind) encode(value string) json.RawMessage {
+	if k == kindInt {
+		if _, err := strconv.Atoi(value); err == nil {
+			return json.RawMessage(value)
+		}
+	}
+	data, _ := json.Marshal(value)
+	return data
+}
+
+// maskToken hides a token entirely.
+func maskToken(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "xxxxx"
+}
+
+// maskURL hides the password in a URL so it can be printed. Values that
+// don't parse have everything before the host masked instead.
+func maskURL(value string) string {
+	parsed, err := url.Parse(value)
+	if err == nil {
+		return parsed.Redacted()
+	}
+	scheme, rest, ok := strings.Cut(value, "://")
+	if at := strings.LastIndex(rest, "@"); ok && at >= 0 {
+		return scheme + "://xxxxx" + rest[at:]
+	}
+	return value
+}
@@ -72,7 +72,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 	return i, err
 }
 
-const deleteFeedFollow = `-- name: DeleteFeedFollow :exec
+const deleteFeedFollow = `-- name: DeleteFeedFollow :execrows
 WITH feed_follow AS (
   SELECT id FROM feeds WHERE feeds.url = $1
 ),
@@ -90,21 +90,50 @@ type DeleteFeedFollowParams struct {
 }
 
 // sql
-func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) error {
-	_, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.Url, arg.Name)
-	return err
+func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.Url, arg.Name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFeedFollowForUserByUrl = `-- name: GetFeedFollowForUserByUrl :one
+SELECT feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id FROM feed_follows
+INNER JOIN feeds ON feeds.id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1 AND feeds.url = $2
+`
+
+type GetFeedFollowForUserByUrlParams struct {
+	UserID uuid.UUID
+	Url    string
+}
+
+func (q *Queries) GetFeedFollowForUserByUrl(ctx context.Context, arg GetFeedFollowForUserByUrlParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedFollowForUserByUrl, arg.UserID, arg.Url)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+	)
+	return i, err
 }
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-SELECT users.name as user_name, feeds.name as feed_name, feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id FROM feed_follows 
+SELECT users.name as user_name, feeds.name as feed_name, feeds.url as feed_url, feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id FROM feed_follows 
 INNER JOIN users ON users.id = feed_follows.user_id 
 INNER JOIN feeds ON feeds.id = feed_follows.feed_id 
 WHERE feed_follows.user_id = $1
+ORDER BY feed_follows.created_at, feed_follows.id
 `
 
 type GetFeedFollowsForUserRow struct {
 	UserName  string
 	FeedName  string
+	FeedUrl   string
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
@@ -124,6 +153,7 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 		if err := rows.Scan(
 			&i.UserName,
 			&i.FeedName,
+			&i.FeedUrl,
 			&i.ID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
@@ -142,3 +172,140 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	}
 	return items, nil
 }
+
+const getFollowerIDsForFeed = `-- name: GetFollowerIDsForFeed :many
+SELECT user_id FROM feed_follows WHERE feed_id = $1
+`
+
+func (q *Queries) GetFollowerIDsForFeed(ctx context.Context, feedID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowerIDsForFeed, feedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var user_id uuid.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFollowedFeedsWithUnreadCounts = `-- name: GetFollowedFeedsWithUnreadCounts :many
+SELECT feeds.id, feeds.name, feeds.url, COUNT(posts.id) AS unread
+FROM feed_follows
+INNER JOIN feeds ON feeds.id = feed_follows.feed_id
+LEFT JOIN posts ON posts.feed_id = feeds.id AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+      AND post_states.user_id = feed_follows.user_id
+      AND (post_states.read_at IS NOT NULL OR post_states.hidden_by_rule IS NOT NULL)
+)
+WHERE feed_follows.user_id = $1
+GROUP BY feeds.id, feeds.name, feeds.url
+ORDER BY feeds.name
+`
+
+type GetFollowedFeedsWithUnreadCountsRow struct {
+	ID     uuid.UUID
+	Name   string
+	Url    string
+	Unread int64
+}
+
+func (q *Queries) GetFollowedFeedsWithUnreadCounts(ctx context.Context, userID uuid.UUID) ([]GetFollowedFeedsWithUnreadCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedFeedsWithUnreadCounts, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFollowedFeedsWithUnreadCountsRow
+	for rows.Next() {
+		var i GetFollowedFeedsWithUnreadCountsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.Unread,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOldestOtherFollower = `-- name: GetOldestOtherFollower :one
+SELECT user_id FROM feed_follows
+WHERE feed_id = $1 AND user_id <> $2
+ORDER BY created_at, id
+LIMIT 1
+`
+
+type GetOldestOtherFollowerParams struct {
+	FeedID uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetOldestOtherFollower(ctx context.Context, arg GetOldestOtherFollowerParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getOldestOtherFollower, arg.FeedID, arg.UserID)
+	var user_id uuid.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const getFollowedFeedsForUser = `-- name: GetFollowedFeedsForUser :many
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.paused, feeds.number
+FROM feed_follows
+INNER JOIN feeds ON feeds.id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1
+ORDER BY feeds.number
+`
+
+func (q *Queries) GetFollowedFeedsForUser(ctx context.Context, userID uuid.UUID) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedFeedsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Paused,
+			&i.Number,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
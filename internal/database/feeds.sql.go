@@ -14,16 +14,17 @@ import (
 )
 
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, number)
 VALUES(
     $1,
     $2, 
     $3, 
     $4, 
     $5,
-    $6
+    $6,
+    (SELECT COALESCE(MAX(number), 0) + 1 FROM feeds)
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, paused, number
 `
 
 type CreateFeedParams struct {
@@ -53,12 +54,44 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Paused,
+		&i.Number,
+	)
+	return i, err
+}
+
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
+const getFeedById = `-- name: GetFeedById :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, paused, number FROM feeds WHERE id = $1
+`
+
+func (q *Queries) GetFeedById(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedById, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Paused,
+		&i.Number,
 	)
 	return i, err
 }
 
 const getFeedByUrl = `-- name: GetFeedByUrl :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds WHERE url = $1
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, paused, number FROM feeds WHERE url = $1
 `
 
 func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
@@ -72,12 +105,14 @@ func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Paused,
+		&i.Number,
 	)
 	return i, err
 }
 
 const getFeeds = `-- name: GetFeeds :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, paused, number FROM feeds
 `
 
 func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
@@ -97,6 +132,53 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.Paused,
+			&i.Number,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedsOwnedByUser = `-- name: GetFeedsOwnedByUser :many
+SELECT feeds.id, feeds.name, feeds.url, (
+    SELECT COUNT(*) FROM feed_follows
+    WHERE feed_follows.feed_id = feeds.id AND feed_follows.user_id <> feeds.user_id
+) AS other_followers
+FROM feeds
+WHERE feeds.user_id = $1
+ORDER BY feeds.name
+`
+
+type GetFeedsOwnedByUserRow struct {
+	ID             uuid.UUID
+	Name           string
+	Url            string
+	OtherFollowers int64
+}
+
+func (q *Queries) GetFeedsOwnedByUser(ctx context.Context, userID uuid.UUID) ([]GetFeedsOwnedByUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsOwnedByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedsOwnedByUserRow
+	for rows.Next() {
+		var i GetFeedsOwnedByUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.OtherFollowers,
 		); err != nil {
 			return nil, err
 		}
@@ -112,8 +194,9 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 }
 
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, paused, number
 FROM feeds
+WHERE NOT paused
 ORDER BY last_fetched_at NULLS FIRST, id
 LIMIT 1
 `
@@ -129,6 +212,8 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Paused,
+		&i.Number,
 	)
 	return i, err
 }
@@ -149,3 +234,78 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams
 	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.LastFetchedAt, arg.UpdatedAt, arg.ID)
 	return err
 }
+
+const renameFeed = `-- name: RenameFeed :exec
+UPDATE feeds SET name = $2, updated_at = $3
+WHERE id = $1
+`
+
+type RenameFeedParams struct {
+	ID        uuid.UUID
+	Name      string
+	UpdatedAt time.Time
+}
+
+func (q *Queries) RenameFeed(ctx context.Context, arg RenameFeedParams) error {
+	_, err := q.db.ExecContext(ctx, renameFeed, arg.ID, arg.Name, arg.UpdatedAt)
+	return err
+}
+
+const setFeedOwner = `-- name: SetFeedOwner :exec
+UPDATE feeds SET user_id = $2, updated_at = $3
+WHERE id = $1
+`
+
+type SetFeedOwnerParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	UpdatedAt time.Time
+}
+
+func (q *Queries) SetFeedOwner(ctx context.Context, arg SetFeedOwnerParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedOwner, arg.ID, arg.UserID, arg.UpdatedAt)
+	return err
+}
+
+const setFeedPaused = `-- name: SetFeedPaused :exec
+UPDATE feeds SET paused = $2, updated_at = $3
+WHERE id = $1
+`
+
+type SetFeedPausedParams struct {
+	ID        uuid.UUID
+	Paused    bool
+	UpdatedAt time.Time
+}
+
+func (q *Queries) SetFeedPaused(ctx context.Context, arg SetFeedPausedParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedPaused, arg.ID, arg.Paused, arg.UpdatedAt)
+	return err
+}
+
+const setFeedUrl = `-- name: SetFeedUrl :exec
+UPDATE feeds SET url = $2, updated_at = $3
+WHERE id = $1
+`
+
+type SetFeedUrlParams struct {
+	ID        uuid.UUID
+	Url       string
+	UpdatedAt time.Time
+}
+
+func (q *Queries) SetFeedUrl(ctx context.Context, arg SetFeedUrlParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedUrl, arg.ID, arg.Url, arg.UpdatedAt)
+	return err
+}
+
+const lockFeedNumbers = `-- name: LockFeedNumbers :exec
+SELECT pg_advisory_xact_lock(hashtext('gator.feeds.number'))
+`
+
+// Holds feed numbering until the transaction ends, so feeds added at the
+// same time get numbers one after another.
+func (q *Queries) LockFeedNumbers(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, lockFeedNumbers)
+	return err
+}
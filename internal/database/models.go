@@ -11,6 +11,15 @@ import (
 	"github.com/google/uuid"
 )
 
+type ApiToken struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UserID     uuid.UUID
+	Name       string
+	TokenHash  string
+	LastUsedAt sql.NullTime
+}
+
 type Feed struct {
 	ID            uuid.UUID
 	CreatedAt     time.Time
@@ -19,6 +28,8 @@ type Feed struct {
 	Url           string
 	UserID        uuid.UUID
 	LastFetchedAt sql.NullTime
+	Paused        bool
+	Number        int64
 }
 
 type FeedFollow struct {
@@ -29,9 +40,67 @@ type FeedFollow struct {
 	FeedID    uuid.UUID
 }
 
-type User struct {
+type FeedFollowTag struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	FeedFollowID uuid.UUID
+	Name         string
+}
+
+type FilterRule struct {
 	ID        uuid.UUID
-	Name      string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	UserID    uuid.UUID
+	FeedID    uuid.NullUUID
+	Field     string
+	MatchType string
+	Pattern   string
+	Action    string
+}
+
+type Post struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.UUID
+	Author      sql.NullString
+	Categories  sql.NullString
+	Number      int64
+}
+
+type PostState struct {
+	ID                uuid.UUID
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	UserID            uuid.UUID
+	PostID            uuid.UUID
+	ReadAt            sql.NullTime
+	StarredAt         sql.NullTime
+	ReadByRule        uuid.NullUUID
+	StarredByRule     uuid.NullUUID
+	HiddenByRule      uuid.NullUUID
+	HighlightedByRule uuid.NullUUID
+}
+
+type Session struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UserID    uuid.UUID
+	TokenHash string
+}
+
+type User struct {
+	ID           uuid.UUID
+	Name         string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	PasswordHash sql.NullString
+	Role         string
 }
@@ -0,0 +1,365 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// versionTable matches the table goose uses, so databases migrated with goose
+// keep working with the built-in runner and vice versa.
+const versionTable = "goose_db_version"
+
+// Dialect selects the SQL flavour used for the version table.
+type Dialect string
+
+// Supported dialects.
+const (
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+// Migration is a single numbered schema change with its up and down SQL.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status describes whether a migration has been applied to a database.
+type Status struct {
+	Migration Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Load reads goose-style migration files ("001_name.sql" with
+// "-- +goose Up" and "-- +goose Down" sections) from fsys, sorted by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(files))
+	seen := map[int64]string{}
+	for _, file := range files {
+		prefix, _, ok := strings.Cut(path.Base(file), "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: name must start with a version number", file)
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("migration %s: invalid version %q", file, prefix)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, file, version)
+		}
+		seen[version] = file
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		up, down, err := parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", file, err)
+		}
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    file,
+			Up:      up,
+			Down:    down,
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// parse splits a migration file into its up and down sections.
+func parse(contents string) (string, string, error) {
+	var up, down strings.Builder
+	var current *strings.Builder
+
+	for _, line := range strings.SplitAfter(contents, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "-- +goose") {
+			switch strings.TrimSpace(strings.TrimPrefix(trimmed, "-- +goose")) {
+			case "Up":
+				current = &up
+			case "Down":
+				current = &down
+			}
+			// StatementBegin/End and other annotations need no handling
+			// because each section runs as a single Exec.
+			continue
+		}
+		if current != nil {
+			current.WriteString(line)
+		}
+	}
+
+	if strings.TrimSpace(up.String()) == "" {
+		return "", "", fmt.Errorf("missing -- +goose Up section")
+	}
+	return strings.TrimSpace(up.String()), strings.TrimSpace(down.String()), nil
+}
+
+// Migrator applies migrations to a database and tracks the applied versions.
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// New creates a Migrator for the given database, dialect and migrations.
+func New(db *sql.DB, dialect Dialect, migrations []Migration) *Migrator {
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}
+}
+
+// Latest returns the highest known migration version.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the highest applied migration version, or 0 for an empty database.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Status reports every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		at, ok := applied[migration.Version]
+		statuses = append(statuses, Status{Migration: migration, Applied: ok, AppliedAt: at})
+	}
+	return statuses, nil
+}
+
+// Up applies every pending migration in order and returns the ones it ran.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.To(ctx, m.Latest())
+}
+
+// Down rolls back the most recently applied migration.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("no migrations to roll back")
+	}
+
+	migration, ok := m.find(version)
+	if !ok {
+		return nil, fmt.Errorf("applied version %d has no migration file", version)
+	}
+	if err := m.run(ctx, migration, false); err != nil {
+		return nil, err
+	}
+	return &migration, nil
+}
+
+// To migrates up or down until the database is at the target version and
+// returns the migrations it ran in order.
+func (m *Migrator) To(ctx context.Context, target int64) ([]Migration, error) {
+	if target != 0 {
+		if _, ok := m.find(target); !ok {
+			return nil, fmt.Errorf("unknown migration version %d", target)
+		}
+	}
+
+	if err := m.ensureVersionTable(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	// Apply pending migrations up to the target, oldest first.
+	for _, migration := range m.migrations {
+		if migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := m.run(ctx, migration, true); err != nil {
+			return ran, err
+		}
+		ran = append(ran, migration)
+	}
+
+	// Roll back applied migrations above the target, newest first.
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version <= target {
+			break
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if err := m.run(ctx, migration, false); err != nil {
+			return ran, err
+		}
+		ran = append(ran, migration)
+	}
+
+	return ran, nil
+}
+
+// CheckCurrent returns an error unless every known migration has been applied
+// and the database is not ahead of this binary.
+func (m *Migrator) CheckCurrent(ctx context.Context) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	latest := m.Latest()
+	switch {
+	case version < latest:
+		return fmt.Errorf("database schema is at version %d but %d is required: run `gator migrate up`", version, latest)
+	case version > latest:
+		return fmt.Errorf("database schema is at version %d, newer than this gator supports (%d)", version, latest)
+	}
+	return nil
+}
+
+// run applies or rolls back a single migration inside a transaction.
+func (m *Migrator) run(ctx context.Context, migration Migration, up bool) error {
+	statement := migration.Up
+	if !up {
+		statement = migration.Down
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if statement != "" {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("migration %s: %w", migration.Name, err)
+		}
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO "+versionTable+" (version_id, is_applied, tstamp) VALUES ($1, $2, $3)",
+			migration.Version, true, time.Now())
+	} else {
+		_, err = tx.ExecContext(ctx,
+			"DELETE FROM "+versionTable+" WHERE version_id = $1", migration.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// applied returns the applied versions with the time each was applied. It
+// only reads, so a database without the version table has none applied.
+func (m *Migrator) applied(ctx context.Context) (map[int64]time.Time, error) {
+	exists, err := m.versionTableExists(ctx)
+	if err != nil || !exists {
+		return map[int64]time.Time{}, err
+	}
+
+	rows, err := m.db.QueryContext(ctx,
+		"SELECT version_id, is_applied, tstamp FROM "+versionTable+" ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Replay the log so rows written by older goose versions, which record
+	// rollbacks as is_applied = false, are handled too.
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var at sql.NullTime
+		if err := rows.Scan(&version, &isApplied, &at); err != nil {
+			return nil, err
+		}
+		if version == 0 {
+			continue
+		}
+		if isApplied {
+			applied[version] = at.Time
+		} else {
+			delete(applied, version)
+		}
+	}
+	return applied, rows.Err()
+}
+
+// versionTableExists reports whether the version table has been created.
+func (m *Migrator) versionTableExists(ctx context.Context) (bool, error) {
+	query := "SELECT to_regclass('" + versionTable + "') IS NOT NULL"
+	if m.dialect == SQLite {
+		query = "SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = '" + versionTable + "')"
+	}
+	var exists bool
+	err := m.db.QueryRowContext(ctx, query).Scan(&exists)
+	return exists, err
+}
+
+// ensureVersionTable creates the version table if it doesn't exist yet.
+func (m *Migrator) ensureVersionTable(ctx context.Context) error {
+	idColumn := "id SERIAL PRIMARY KEY"
+	if m.dialect == SQLite {
+		idColumn = "id INTEGER PRIMARY KEY AUTOINCREMENT"
+	}
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+versionTable+` (
+    `+idColumn+`,
+    version_id BIGINT NOT NULL,
+    is_applied BOOLEAN NOT NULL,
+    tstamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+)`)
+	return err
+}
+
+// find returns the migration with the given version.
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
@@ -0,0 +1,249 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		up       string
+		down     string
+		err      string
+	}{
+		{
+			name:     "up and down",
+			contents: "-- +goose Up\nCREATE TABLE a (id INT);\n\n-- +goose Down\nDROP TABLE a;\n",
+			up:       "CREATE TABLE a (id INT);",
+			down:     "DROP TABLE a;",
+		},
+		{
+			name:     "empty down",
+			contents: "-- +goose Up\nCREATE TABLE a (id INT);\n-- +goose Down\n",
+			up:       "CREATE TABLE a (id INT);",
+		},
+		{
+			name:     "no down",
+			contents: "-- +goose Up\nCREATE TABLE a (id INT);\n",
+			up:       "CREATE TABLE a (id INT);",
+		},
+		{
+			name:     "down first",
+			contents: "-- +goose Down\nDROP TABLE a;\n-- +goose Up\nCREATE TABLE a (id INT);\n",
+			up:       "CREATE TABLE a (id INT);",
+			down:     "DROP TABLE a;",
+		},
+		{
+			name:     "text before the first section is ignored",
+			contents: "-- creates a\n-- +goose Up\nCREATE TABLE a (id INT);\n",
+			up:       "CREATE TABLE a (id INT);",
+		},
+		{
+			name: "statement annotations are dropped",
+			contents: "-- +goose Up\n-- +goose StatementBegin\nCREATE TABLE a (id INT);\n-- +goose StatementEnd\n" +
+				"  -- +goose Down\nDROP TABLE a;\n",
+			up:   "CREATE TABLE a (id INT);",
+			down: "DROP TABLE a;",
+		},
+		{
+			name:     "multiple statements",
+			contents: "-- +goose Up\nCREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n",
+			up:       "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);",
+		},
+		{
+			name:     "no up",
+			contents: "-- +goose Down\nDROP TABLE a;\n",
+			err:      "missing -- +goose Up section",
+		},
+		{
+			name:     "empty up",
+			contents: "-- +goose Up\n\n-- +goose Down\nDROP TABLE a;\n",
+			err:      "missing -- +goose Up section",
+		},
+		{
+			name: "empty file",
+			err:  "missing -- +goose Up section",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up, down, err := parse(tt.contents)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("parse error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if up != tt.up || down != tt.down {
+				t.Errorf("parse = %q, %q, want %q, %q", up, down, tt.up, tt.down)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	const valid = "-- +goose Up\nSELECT 1;\n-- +goose Down\nSELECT 2;\n"
+	tests := []struct {
+		name     string
+		files    fstest.MapFS
+		versions []int64
+		err      string
+	}{
+		{
+			name:  "empty",
+			files: fstest.MapFS{},
+		},
+		{
+			name: "sorted by version, not name",
+			files: fstest.MapFS{
+				"10_ten.sql":    {Data: []byte(valid)},
+				"002_two.sql":   {Data: []byte(valid)},
+				"9_nine.sql":    {Data: []byte(valid)},
+				"001_first.sql": {Data: []byte(valid)},
+			},
+			versions: []int64{1, 2, 9, 10},
+		},
+		{
+			name: "other files are ignored",
+			files: fstest.MapFS{
+				"001_first.sql": {Data: []byte(valid)},
+				"README.md":     {Data: []byte("notes")},
+				"sub/002_x.sql": {Data: []byte(valid)},
+			},
+			versions: []int64{1},
+		},
+		{
+			name: "duplicate versions",
+			files: fstest.MapFS{
+				"001_first.sql": {Data: []byte(valid)},
+				"1_again.sql":   {Data: []byte(valid)},
+			},
+			err: "share version 1",
+		},
+		{
+			name:  "no version",
+			files: fstest.MapFS{"users.sql": {Data: []byte(valid)}},
+			err:   "migration users.sql: name must start with a version number",
+		},
+		{
+			name:  "version isn't a number",
+			files: fstest.MapFS{"first_users.sql": {Data: []byte(valid)}},
+			err:   `migration first_users.sql: invalid version "first"`,
+		},
+		{
+			name:  "version zero",
+			files: fstest.MapFS{"000_users.sql": {Data: []byte(valid)}},
+			err:   `migration 000_users.sql: invalid version "000"`,
+		},
+		{
+			name:  "missing up section",
+			files: fstest.MapFS{"001_users.sql": {Data: []byte("-- +goose Down\nSELECT 2;\n")}},
+			err:   "migration 001_users.sql: missing -- +goose Up section",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := Load(tt.files)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Load error = %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(migrations) != len(tt.versions) {
+				t.Fatalf("Load returned %d migrations, want %d", len(migrations), len(tt.versions))
+			}
+			for i, migration := range migrations {
+				if migration.Version != tt.versions[i] {
+					t.Errorf("migration %d has version %d, want %d", i, migration.Version, tt.versions[i])
+				}
+				if migration.Up != "SELECT 1;" || migration.Down != "SELECT 2;" {
+					t.Errorf("migration %s = %q, %q", migration.Name, migration.Up, migration.Down)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrations, err := Load(fstest.MapFS{
+		"001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id INT);\n-- +goose Down\nDROP TABLE a;\n")},
+		// Without a Down section, rolling back only forgets the version.
+		"002_b.sql": {Data: []byte("-- +goose Up\nCREATE TABLE b (id INT);\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator := New(db, SQLite, migrations)
+
+	// Checking an empty database reads it without creating the version table.
+	if err := migrator.CheckCurrent(ctx); err == nil || !strings.Contains(err.Error(), "gator migrate up") {
+		t.Errorf("CheckCurrent on an empty database = %v", err)
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil || len(statuses) != 2 || statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("Status on an empty database = %+v, %v", statuses, err)
+	}
+	if exists, err := migrator.versionTableExists(ctx); err != nil || exists {
+		t.Errorf("version table exists after checking = %v, %v", exists, err)
+	}
+	ran, err := migrator.Up(ctx)
+	if err != nil || len(ran) != 2 {
+		t.Fatalf("Up ran %d migrations, %v", len(ran), err)
+	}
+	if err := migrator.CheckCurrent(ctx); err != nil {
+		t.Errorf("CheckCurrent after Up = %v", err)
+	}
+	if ran, err := migrator.Up(ctx); err != nil || len(ran) != 0 {
+		t.Errorf("Up again ran %d migrations, %v", len(ran), err)
+	}
+
+	rolledBack, err := migrator.Down(ctx)
+	if err != nil || rolledBack.Version != 2 {
+		t.Fatalf("Down = %+v, %v", rolledBack, err)
+	}
+	statuses, err = migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || !statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("Status after Down = %+v", statuses)
+	}
+
+	if _, err := migrator.To(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if version, err := migrator.Version(ctx); err != nil || version != 0 {
+		t.Errorf("Version after migrating to 0 = %d, %v", version, err)
+	}
+	if _, err := db.ExecContext(ctx, "SELECT * FROM a"); err == nil {
+		t.Error("table a survived rolling back its migration")
+	}
+	if _, err := migrator.Down(ctx); err == nil {
+		t.Error("Down on an empty database should fail")
+	}
+	if _, err := migrator.To(ctx, 3); err == nil {
+		t.Error("To an unknown version should fail")
+	}
+}
@@ -0,0 +1,223 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+)
+
+// Format names accepted by Parse. Templates are given as "template=<text>".
+const (
+	Text     = "text"
+	JSON     = "json"
+	NDJSON   = "ndjson"
+	CSV      = "csv"
+	Table    = "table"
+	Template = "template"
+)
+
+// Format is a parsed output format.
+type Format struct {
+	Name string
+	tmpl *template.Template
+}
+
+// Parse parses an --output value. An empty value selects the human-readable
+// text output each command prints by default.
+func Parse(spec string) (Format, error) {
+	switch spec {
+	case "", Text:
+		return Format{Name: Text}, nil
+	case JSON, NDJSON, CSV, Table:
+		return Format{Name: spec}, nil
+	}
+
+	if text, ok := strings.CutPrefix(spec, Template+"="); ok {
+		tmpl, err := template.New("output").Parse(text)
+		if err != nil {
+			return Format{}, fmt.Errorf("invalid output template: %w", err)
+		}
+		return Format{Name: Template, tmpl: tmpl}, nil
+	}
+
+	return Format{}, fmt.Errorf("unknown output format %q: use text, json, ndjson, csv, table or template=<go template>", spec)
+}
+
+// IsText reports whether the command's own human-readable output should be used.
+func (f Format) IsText() bool {
+	return f.Name == "" || f.Name == Text
+}
+
+// Write renders a slice of records, which must be structs or pointers to
+// structs. Field names and column order come from the fields' json tags.
+func Write(w io.Writer, f Format, records any) error {
+	v := reflect.ValueOf(records)
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("output: records must be a slice, got %T", records)
+	}
+
+	enc := NewEncoder(w, f)
+	for i := 0; i < v.Len(); i++ {
+		if err := enc.Encode(v.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+// Encoder renders records one at a time, for commands that stream results.
+type Encoder struct {
+	w       io.Writer
+	format  Format
+	pending []any
+	csv     *csv.Writer
+	table   *tabwriter.Writer
+	wrote   bool
+}
+
+// NewEncoder creates an Encoder. JSON output is buffered until Close so the
+// result is a single array; every other format is written as it goes.
+func NewEncoder(w io.Writer, f Format) *Encoder {
+	return &Encoder{w: w, format: f}
+}
+
+// Encode renders a single record.
+func (e *Encoder) Encode(record any) error {
+	switch e.format.Name {
+	case JSON:
+		e.pending = append(e.pending, record)
+		return nil
+	case NDJSON:
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(e.w, "%s\n", data)
+		return err
+	case CSV:
+		columns, values, err := fields(record)
+		if err != nil {
+			return err
+		}
+		if e.csv == nil {
+			e.csv = csv.NewWriter(e.w)
+			if err := e.csv.Write(columns); err != nil {
+				return err
+			}
+		}
+		if err := e.csv.Write(values); err != nil {
+			return err
+		}
+		e.csv.Flush()
+		return e.csv.Error()
+	case Table:
+		columns, values, err := fields(record)
+		if err != nil {
+			return err
+		}
+		if e.table == nil {
+			e.table = tabwriter.NewWriter(e.w, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(e.table, strings.ToUpper(strings.Join(columns, "\t")))
+		}
+		_, err = fmt.Fprintln(e.table, strings.Join(values, "\t"))
+		return err
+	case Template:
+		if err := e.format.tmpl.Execute(e.w, record); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(e.w)
+		return err
+	default:
+		return fmt.Errorf("output: format %q has no encoder", e.format.Name)
+	}
+}
+
+// Flush writes out buffered table rows, aligning the rows seen so far.
+func (e *Encoder) Flush() error {
+	if e.table != nil {
+		return e.table.Flush()
+	}
+	return nil
+}
+
+// Close finishes the output, writing the JSON array or remaining table rows.
+func (e *Encoder) Close() error {
+	if e.format.Name == JSON {
+		records := e.pending
+		if records == nil {
+			records = []any{}
+		}
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(e.w, "%s\n", data)
+		return err
+	}
+	return e.Flush()
+}
+
+// fields returns the column names and formatted values of a struct record.
+func fields(record any) ([]string, []string, error) {
+	v := reflect.ValueOf(record)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("output: records must be structs, got %T", record)
+	}
+
+	var columns, values []string
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, name)
+		values = append(values, formatValue(v.Field(i)))
+	}
+	return columns, values, nil
+}
+
+// formatValue renders a single field for CSV and table output.
+func formatValue(v reflect.Value) string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch value := v.Interface().(type) {
+	case time.Time:
+		if value.IsZero() {
+			return ""
+		}
+		return value.Format(time.RFC3339)
+	case fmt.Stringer:
+		return value.String()
+	}
+
+	if v.Kind() == reflect.Slice {
+		parts := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			parts = append(parts, formatValue(v.Index(i)))
+		}
+		return strings.Join(parts, ";")
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,204 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type record struct {
+	Name   string    `json:"name"`
+	Count  int       `json:"count"`
+	Tags   []string  `json:"tags"`
+	Seen   time.Time `json:"seen"`
+	Note   *string   `json:"note,omitempty"`
+	Secret string    `json:"-"`
+	Plain  bool
+	hidden string
+}
+
+func testRecords() []record {
+	note := "new"
+	return []record{
+		{Name: "Go Blog", Count: 3, Tags: []string{"go", "news"}, Seen: time.Date(2025, 8, 12, 9, 30, 0, 0, time.UTC), Note: &note, Secret: "x", Plain: true},
+		{Name: "Hacker, News", Count: 12, hidden: "y"},
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec string
+		name string
+		err  string
+	}{
+		{spec: "", name: Text},
+		{spec: "text", name: Text},
+		{spec: "json", name: JSON},
+		{spec: "ndjson", name: NDJSON},
+		{spec: "csv", name: CSV},
+		{spec: "table", name: Table},
+		{spec: "template={{.name}}", name: Template},
+		{spec: "yaml", err: `unknown output format "yaml"`},
+		{spec: "JSON", err: `unknown output format "JSON"`},
+		{spec: "template", err: `unknown output format "template"`},
+		{spec: "template={{.name", err: "invalid output template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			f, err := Parse(tt.spec)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Parse error = %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Name != tt.name {
+				t.Errorf("Parse(%q).Name = %q, want %q", tt.spec, f.Name, tt.name)
+			}
+			if f.IsText() != (tt.name == Text) {
+				t.Errorf("Parse(%q).IsText() = %v", tt.spec, f.IsText())
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		spec    string
+		records any
+		want    string
+	}{
+		{
+			spec:    "json",
+			records: testRecords()[1:],
+			want: `[
+  {
+    "name": "Hacker, News",
+    "count": 12,
+    "tags": null,
+    "seen": "0001-01-01T00:00:00Z",
+    "Plain": false
+  }
+]
+`,
+		},
+		{spec: "json", records: []record{}, want: "[]\n"},
+		{
+			spec:    "ndjson",
+			records: testRecords(),
+			want: `{"name":"Go Blog","count":3,"tags":["go","news"],"seen":"2025-08-12T09:30:00Z","note":"new","Plain":true}
+{"name":"Hacker, News","count":12,"tags":null,"seen":"0001-01-01T00:00:00Z","Plain":false}
+`,
+		},
+		{spec: "ndjson", records: []record{}, want: ""},
+		{
+			spec:    "csv",
+			records: testRecords(),
+			want: `name,count,tags,seen,note,Plain
+Go Blog,3,go;news,2025-08-12T09:30:00Z,new,true
+"Hacker, News",12,,,,false
+`,
+		},
+		{spec: "csv", records: []record{}, want: ""},
+		{
+			spec:    "table",
+			records: testRecords(),
+			want: `NAME          COUNT  TAGS     SEEN                  NOTE  PLAIN
+Go Blog       3      go;news  2025-08-12T09:30:00Z  new   true
+Hacker, News  12                                          false
+`,
+		},
+		{
+			spec:    "table",
+			records: []*record{&testRecords()[0]},
+			want: `NAME     COUNT  TAGS     SEEN                  NOTE  PLAIN
+Go Blog  3      go;news  2025-08-12T09:30:00Z  new   true
+`,
+		},
+		{
+			spec:    "template={{.Name}} ({{.Count}})",
+			records: testRecords(),
+			want:    "Go Blog (3)\nHacker, News (12)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			f, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out strings.Builder
+			if err := Write(&out, f, tt.records); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("Write = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	tests := []struct {
+		spec    string
+		records any
+		err     string
+	}{
+		{spec: "json", records: testRecords()[0], err: "records must be a slice"},
+		{spec: "csv", records: []string{"a"}, err: "records must be structs"},
+		{spec: "table", records: []int{1}, err: "records must be structs"},
+		{spec: "text", records: testRecords(), err: `format "text" has no encoder`},
+		{spec: "template={{.missing.field}}", records: testRecords(), err: "missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			f, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out strings.Builder
+			err = Write(&out, f, tt.records)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Write error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestEncoderStreams(t *testing.T) {
+	for _, spec := range []string{"ndjson", "csv"} {
+		t.Run(spec, func(t *testing.T) {
+			f, err := Parse(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out strings.Builder
+			enc := NewEncoder(&out, f)
+			if err := enc.Encode(testRecords()[0]); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out.String(), "Go Blog") {
+				t.Errorf("%s record wasn't written before Close: %q", spec, out.String())
+			}
+		})
+	}
+
+	f, err := Parse("table")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	enc := NewEncoder(&out, f)
+	if err := enc.Encode(testRecords()[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Go Blog") {
+		t.Errorf("table row wasn't written by Flush: %q", out.String())
+	}
+}
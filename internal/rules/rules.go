@@ -0,0 +1,168 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Fields a rule can match against.
+const (
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldAuthor      = "author"
+	FieldCategory    = "category"
+)
+
+// Ways a rule's pattern can be matched.
+const (
+	MatchSubstring = "substring"
+	MatchRegex     = "regex"
+)
+
+// Actions a matching rule can take on a post.
+const (
+	ActionHide      = "hide"
+	ActionMarkRead  = "mark-read"
+	ActionHighlight = "highlight"
+	ActionStar      = "star"
+)
+
+// Rule is a validated, ready-to-evaluate filter rule.
+type Rule struct {
+	ID        uuid.UUID
+	FeedID    uuid.NullUUID
+	Field     string
+	MatchType string
+	Pattern   string
+	Action    string
+
+	re *regexp.Regexp
+}
+
+// Item holds the parts of a post that rules can inspect.
+type Item struct {
+	FeedID      uuid.UUID
+	Title       string
+	Description string
+	Author      string
+	Categories  []string
+}
+
+// Result collects the actions triggered by the rules that matched an item,
+// each with the ID of the first rule that took it.
+type Result struct {
+	Hide      uuid.NullUUID
+	MarkRead  uuid.NullUUID
+	Highlight uuid.NullUUID
+	Star      uuid.NullUUID
+}
+
+// Any reports whether at least one action was triggered.
+func (r Result) Any() bool {
+	return r.Hide.Valid || r.MarkRead.Valid || r.Highlight.Valid || r.Star.Valid
+}
+
+// Compile validates a rule's field, match type and action and prepares its pattern.
+func Compile(id uuid.UUID, feedID uuid.NullUUID, field, matchType, pattern, action string) (Rule, error) {
+	rule := Rule{
+		ID:        id,
+		FeedID:    feedID,
+		Field:     field,
+		MatchType: matchType,
+		Pattern:   pattern,
+		Action:    action,
+	}
+
+	switch field {
+	case FieldTitle, FieldDescription, FieldAuthor, FieldCategory:
+	default:
+		return Rule{}, fmt.Errorf("unknown field %q: use title, description, author or category", field)
+	}
+
+	switch action {
+	case ActionHide, ActionMarkRead, ActionHighlight, ActionStar:
+	default:
+		return Rule{}, fmt.Errorf("unknown action %q: use hide, mark-read, highlight or star", action)
+	}
+
+	if pattern == "" {
+		return Rule{}, fmt.Errorf("pattern cannot be empty")
+	}
+
+	switch matchType {
+	case MatchSubstring:
+	case MatchRegex:
+		// Regex rules are case-insensitive, like substring rules.
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid regex %q: %w", pattern, err)
+		}
+		rule.re = re
+	default:
+		return Rule{}, fmt.Errorf("unknown match type %q: use substring or regex", matchType)
+	}
+
+	return rule, nil
+}
+
+// Matches reports whether the rule applies to the item.
+func (r Rule) Matches(item Item) bool {
+	if r.FeedID.Valid && r.FeedID.UUID != item.FeedID {
+		return false
+	}
+
+	switch r.Field {
+	case FieldTitle:
+		return r.matchString(item.Title)
+	case FieldDescription:
+		return r.matchString(item.Description)
+	case FieldAuthor:
+		return r.matchString(item.Author)
+	case FieldCategory:
+		for _, category := range item.Categories {
+			if r.matchString(category) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchString matches a single value using the rule's match type.
+func (r Rule) matchString(value string) bool {
+	if r.re != nil {
+		return r.re.MatchString(value)
+	}
+	return strings.Contains(strings.ToLower(value), strings.ToLower(r.Pattern))
+}
+
+// Evaluate runs every rule against the item and merges their actions. When
+// several rules take the same action, the earliest in rules is credited.
+func Evaluate(rules []Rule, item Item) Result {
+	var result Result
+	for _, rule := range rules {
+		if !rule.Matches(item) {
+			continue
+		}
+		var taken *uuid.NullUUID
+		switch rule.Action {
+		case ActionHide:
+			taken = &result.Hide
+		case ActionMarkRead:
+			taken = &result.MarkRead
+		case ActionHighlight:
+			taken = &result.Highlight
+		case ActionStar:
+			taken = &result.Star
+		default:
+			continue
+		}
+		if !taken.Valid {
+			*taken = uuid.NullUUID{UUID: rule.ID, Valid: true}
+		}
+	}
+	return result
+}
@@ -0,0 +1,140 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func mustCompile(t *testing.T, feedID uuid.NullUUID, field, matchType, pattern, action string) Rule {
+	t.Helper()
+	rule, err := Compile(uuid.New(), feedID, field, matchType, pattern, action)
+	if err != nil {
+		t.Fatalf("Compile(%q, %q, %q, %q) = %v", field, matchType, pattern, action, err)
+	}
+	return rule
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name      string
+		field     string
+		matchType string
+		pattern   string
+		action    string
+		err       string
+	}{
+		{name: "substring", field: FieldTitle, matchType: MatchSubstring, pattern: "go", action: ActionHide},
+		{name: "regex", field: FieldCategory, matchType: MatchRegex, pattern: `^go(lang)?$`, action: ActionStar},
+		{name: "unknown field", field: "body", matchType: MatchSubstring, pattern: "go", action: ActionHide, err: `unknown field "body"`},
+		{name: "unknown action", field: FieldTitle, matchType: MatchSubstring, pattern: "go", action: "delete", err: `unknown action "delete"`},
+		{name: "unknown match type", field: FieldTitle, matchType: "glob", pattern: "go", action: ActionHide, err: `unknown match type "glob"`},
+		{name: "empty pattern", field: FieldTitle, matchType: MatchSubstring, action: ActionHide, err: "pattern cannot be empty"},
+		{name: "invalid regex", field: FieldTitle, matchType: MatchRegex, pattern: "(go", action: ActionHide, err: `invalid regex "(go"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := uuid.New()
+			rule, err := Compile(id, uuid.NullUUID{}, tt.field, tt.matchType, tt.pattern, tt.action)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Compile error = %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rule.ID != id || rule.Field != tt.field || rule.Pattern != tt.pattern || rule.Action != tt.action {
+				t.Errorf("Compile = %+v", rule)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	feedID := uuid.New()
+	item := Item{
+		FeedID:      feedID,
+		Title:       "Go 1.25 Released",
+		Description: "Faster builds and a new GC",
+		Author:      "The Go Team",
+		Categories:  []string{"Programming", "golang"},
+	}
+	tests := []struct {
+		name      string
+		feedID    uuid.NullUUID
+		field     string
+		matchType string
+		pattern   string
+		want      bool
+	}{
+		{name: "title substring", field: FieldTitle, matchType: MatchSubstring, pattern: "released", want: true},
+		{name: "title substring miss", field: FieldTitle, matchType: MatchSubstring, pattern: "rust", want: false},
+		{name: "description substring", field: FieldDescription, matchType: MatchSubstring, pattern: "NEW GC", want: true},
+		{name: "author substring", field: FieldAuthor, matchType: MatchSubstring, pattern: "go team", want: true},
+		{name: "author isn't the title", field: FieldAuthor, matchType: MatchSubstring, pattern: "released", want: false},
+		{name: "any category", field: FieldCategory, matchType: MatchSubstring, pattern: "GOLANG", want: true},
+		{name: "no category", field: FieldCategory, matchType: MatchSubstring, pattern: "rust", want: false},
+		{name: "substring treats regex syntax literally", field: FieldTitle, matchType: MatchSubstring, pattern: "go.*released", want: false},
+		{name: "regex is case-insensitive", field: FieldTitle, matchType: MatchRegex, pattern: `^go \d+\.\d+`, want: true},
+		{name: "regex is anchored per value", field: FieldCategory, matchType: MatchRegex, pattern: `^golang$`, want: true},
+		{name: "regex miss", field: FieldTitle, matchType: MatchRegex, pattern: `^released`, want: false},
+		{name: "same feed", feedID: uuid.NullUUID{UUID: feedID, Valid: true}, field: FieldTitle, matchType: MatchSubstring, pattern: "go", want: true},
+		{name: "other feed", feedID: uuid.NullUUID{UUID: uuid.New(), Valid: true}, field: FieldTitle, matchType: MatchSubstring, pattern: "go", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := mustCompile(t, tt.feedID, tt.field, tt.matchType, tt.pattern, ActionHide)
+			if got := rule.Matches(item); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	item := Item{FeedID: uuid.New(), Title: "Sponsored: Go tips", Author: "ads"}
+	hideSponsored := mustCompile(t, uuid.NullUUID{}, FieldTitle, MatchSubstring, "sponsored", ActionHide)
+	hideAds := mustCompile(t, uuid.NullUUID{}, FieldAuthor, MatchSubstring, "ads", ActionHide)
+	starGo := mustCompile(t, uuid.NullUUID{}, FieldTitle, MatchSubstring, "go", ActionStar)
+	readRust := mustCompile(t, uuid.NullUUID{}, FieldTitle, MatchSubstring, "rust", ActionMarkRead)
+	credit := func(rule Rule) uuid.NullUUID { return uuid.NullUUID{UUID: rule.ID, Valid: true} }
+
+	tests := []struct {
+		name  string
+		rules []Rule
+		want  Result
+	}{
+		{name: "no rules"},
+		{name: "no match", rules: []Rule{readRust}},
+		{name: "one action", rules: []Rule{starGo}, want: Result{Star: credit(starGo)}},
+		{
+			name:  "first rule is credited",
+			rules: []Rule{hideSponsored, hideAds},
+			want:  Result{Hide: credit(hideSponsored)},
+		},
+		{
+			name:  "first rule is credited in any order",
+			rules: []Rule{hideAds, hideSponsored},
+			want:  Result{Hide: credit(hideAds)},
+		},
+		{
+			name:  "actions merge",
+			rules: []Rule{readRust, hideSponsored, starGo},
+			want:  Result{Hide: credit(hideSponsored), Star: credit(starGo)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Evaluate(tt.rules, item)
+			if got != tt.want {
+				t.Errorf("Evaluate = %+v, want %+v", got, tt.want)
+			}
+			if got.Any() != (tt.want != Result{}) {
+				t.Errorf("Any = %v for %+v", got.Any(), got)
+			}
+		})
+	}
+}
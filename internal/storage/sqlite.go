@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/praneeth-ayla/gator/internal/database"
+	"github.com/praneeth-ayla/gator/internal/migrate"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// openSQLite opens an SQLite database at path, or a private in-memory
+// database when path is ":memory:".
+func openSQLite(path string) (Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("sqlite db_url needs a file path, e.g. sqlite:///home/me/gator.db")
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer, and each connection to ":memory:" would
+	// see its own empty database, so share one connection.
+	db.SetMaxOpenConns(1)
+
+	return &sqlStore{
+		Queries: database.New(&sqliteDB{db: db}),
+		db:      db,
+		dialect: migrate.SQLite,
+		// Queries.WithTx would hand the transaction the Postgres queries
+		// as they are, so it gets its own rewriting adapter instead.
+		withTx: func(tx *sql.Tx) *database.Queries {
+			return database.New(&sqliteDB{db: tx})
+		},
+	}, nil
+}
+
+// isSQLiteUniqueViolation reports whether err is SQLite rejecting a duplicate
+// key.
+func isSQLiteUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code()
+	return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+// sqliteDB adapts the Postgres queries generated by sqlc to SQLite by
+// rewriting them before they reach the driver.
+type sqliteDB struct {
+	db database.DBTX
+}
+
+func (s *sqliteDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.db.ExecContext(ctx, toSQLite(query), args...)
+}
+
+func (s *sqliteDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return s.db.PrepareContext(ctx, toSQLite(query))
+}
+
+func (s *sqliteDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return s.db.QueryContext(ctx, toSQLite(query), args...)
+}
+
+func (s *sqliteDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return s.db.QueryRowContext(ctx, toSQLite(query), args...)
+}
+
+// sqliteOverrides replaces queries that use Postgres-only syntax, keyed by
+// their sqlc query name. Parameters keep their Postgres numbering.
+var sqliteOverrides = map[string]string{
+	// SQLite doesn't allow INSERT inside a CTE, so insert first and then
+	// select the joined row.
+	"CreateFeedFollow": `
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+VALUES ($1, $2, $3, $4, $5);
+SELECT
+  feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id,
+  feeds.name AS feed_name,
+  users.name AS user_name
+FROM feed_follows
+INNER JOIN users ON users.id = feed_follows.user_id
+INNER JOIN feeds ON feeds.id = feed_follows.feed_id
+WHERE feed_follows.id = $1`,
+	// SQLite runs one write transaction at a time, so numbers are already
+	// handed out in turn.
+	"LockFeedNumbers": `SELECT 1`,
+	"LockPostNumbers": `SELECT 1`,
+}
+
+var (
+	queryNamePattern   = regexp.MustCompile(`^-- name: (\w+)`)
+	placeholderPattern = regexp.MustCompile(`\$(\d+)`)
+	translated         sync.Map
+)
+
+// toSQLite rewrites a Postgres query for SQLite: overridden queries are
+// swapped out and $N placeholders become ?N, which SQLite binds by position.
+func toSQLite(query string) string {
+	if cached, ok := translated.Load(query); ok {
+		return cached.(string)
+	}
+
+	rewritten := query
+	if match := queryNamePattern.FindStringSubmatch(strings.TrimSpace(query)); match != nil {
+		if override, ok := sqliteOverrides[match[1]]; ok {
+			rewritten = override
+		}
+	}
+	rewritten = placeholderPattern.ReplaceAllString(rewritten, "?$1")
+
+	translated.Store(query, rewritten)
+	return rewritten
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/praneeth-ayla/gator/internal/database"
+	"github.com/praneeth-ayla/gator/internal/migrate"
+)
+
+// Store is the data layer used by the handlers. Every backend exposes the
+// same sqlc-generated queries so handlers never depend on a specific database.
+type Store interface {
+	database.Querier
+
+	// DB returns the underlying connection pool.
+	DB() *sql.DB
+	// Dialect reports which SQL flavour the backend speaks.
+	Dialect() migrate.Dialect
+	// InTx runs fn with a Store whose queries share one transaction, which
+	// commits when fn returns nil and rolls back otherwise. Calling InTx on
+	// that Store joins the same transaction.
+	InTx(ctx context.Context, fn func(tx Store) error) error
+	// Close releases the underlying connection pool.
+	Close() error
+}
+
+// sqlStore runs the generated queries against a database/sql connection.
+type sqlStore struct {
+	*database.Queries
+	db      *sql.DB
+	dialect migrate.Dialect
+	// withTx binds the queries to a transaction, the way the backend needs.
+	withTx func(tx *sql.Tx) *database.Queries
+}
+
+func (s *sqlStore) DB() *sql.DB {
+	return s.db
+}
+
+func (s *sqlStore) Dialect() migrate.Dialect {
+	return s.dialect
+}
+
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *sqlStore) InTx(ctx context.Context, fn func(tx Store) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(&txStore{Queries: s.withTx(tx), parent: s}); err != nil {
+		// The transaction is abandoned either way, so fn's error is the
+		// one worth reporting.
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// txStore is a Store bound to a transaction opened by sqlStore.InTx.
+type txStore struct {
+	*database.Queries
+	parent *sqlStore
+}
+
+func (t *txStore) DB() *sql.DB {
+	return t.parent.db
+}
+
+func (t *txStore) Dialect() migrate.Dialect {
+	return t.parent.dialect
+}
+
+func (t *txStore) InTx(ctx context.Context, fn func(tx Store) error) error {
+	return fn(t)
+}
+
+// Close does nothing: the pool belongs to the Store the transaction was
+// opened from.
+func (t *txStore) Close() error {
+	return nil
+}
+
+// Open connects to the database named by dbURL, choosing the backend from its
+// scheme: postgres:// or postgresql:// for Postgres, sqlite:// for SQLite.
+func Open(dbURL string) (Store, error) {
+	scheme, rest, ok := strings.Cut(dbURL, "://")
+	if !ok {
+		return nil, fmt.Errorf("db_url %q has no scheme: use postgres:// or sqlite://", dbURL)
+	}
+
+	switch scheme {
+	case "postgres", "postgresql":
+		return openPostgres(dbURL)
+	case "sqlite":
+		return openSQLite(rest)
+	default:
+		return nil, fmt.Errorf("unsupported db_url scheme %q: use postgres:// or sqlite://", scheme)
+	}
+}
+
+// openPostgres opens a Postgres connection pool.
+func openPostgres(dbURL string) (Store, error) {
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return nil, err
+	}
+	queries := database.New(db)
+	return &sqlStore{
+		Queries: queries,
+		db:      db,
+		dialect: migrate.Postgres,
+		withTx:  queries.WithTx,
+	}, nil
+}
+
+// IsUniqueViolation reports whether err was raised by a unique constraint,
+// whichever backend the store uses.
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	return isSQLiteUniqueViolation(err)
+}
@@ -0,0 +1,1034 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/praneeth-ayla/gator/internal/database"
+	"github.com/praneeth-ayla/gator/internal/migrate"
+)
+
+// schemaDirs locates each dialect's migrations relative to this package.
+var schemaDirs = map[migrate.Dialect]string{
+	migrate.Postgres: "../../sql/schema",
+	migrate.SQLite:   "../../sql/schema_sqlite",
+}
+
+func TestSQLiteConformance(t *testing.T) {
+	runConformance(t, func(t *testing.T) Store {
+		return openTestStore(t, "sqlite://"+filepath.Join(t.TempDir(), "gator.db"))
+	})
+}
+
+// TestPostgresConformance runs against GATOR_TEST_POSTGRES_URL when set. The
+// database is migrated down and up again, so point it at a throwaway database.
+func TestPostgresConformance(t *testing.T) {
+	dbURL := os.Getenv("GATOR_TEST_POSTGRES_URL")
+	if dbURL == "" {
+		t.Skip("GATOR_TEST_POSTGRES_URL not set")
+	}
+	runConformance(t, func(t *testing.T) Store {
+		return openTestStore(t, dbURL)
+	})
+}
+
+func TestSchemasInSync(t *testing.T) {
+	postgres, err := migrate.Load(os.DirFS(schemaDirs[migrate.Postgres]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlite, err := migrate.Load(os.DirFS(schemaDirs[migrate.SQLite]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postgres) != len(sqlite) {
+		t.Fatalf("postgres has %d migrations, sqlite has %d", len(postgres), len(sqlite))
+	}
+	for i := range postgres {
+		if postgres[i].Name != sqlite[i].Name {
+			t.Errorf("migration %d: postgres %s, sqlite %s", i, postgres[i].Name, sqlite[i].Name)
+		}
+	}
+}
+
+// openTestStore opens a store and migrates it from scratch.
+func openTestStore(t *testing.T, dbURL string) Store {
+	t.Helper()
+	store, err := Open(dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	migrations, err := migrate.Load(os.DirFS(schemaDirs[store.Dialect()]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator := migrate.New(store.DB(), store.Dialect(), migrations)
+	ctx := context.Background()
+	if _, err := migrator.To(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.CheckCurrent(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// runConformance exercises the queries every backend must support.
+func runConformance(t *testing.T, newStore func(t *testing.T) Store) {
+	t.Run("users", func(t *testing.T) {
+		ctx := context.Background()
+		store := newStore(t)
+
+		alice := createUser(t, store, "alice")
+		if _, err := store.CreateUser(ctx, database.CreateUserParams{
+			ID: uuid.New(), Name: "alice", CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		}); !IsUniqueViolation(err) {
+			t.Errorf("duplicate user name returned %v, want a unique violation", err)
+		}
+
+		got, err := store.GetUser(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.ID != alice.ID {
+			t.Errorf("GetUser returned %v, want %v", got.ID, alice.ID)
+		}
+		if _, err := store.GetUser(ctx, "nobody"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetUser for missing user returned %v, want sql.ErrNoRows", err)
+		}
+
+		if err := store.SetUserRole(ctx, database.SetUserRoleParams{
+			ID: alice.ID, Role: "admin", UpdatedAt: time.Now(),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if admins, err := store.CountAdmins(ctx); err != nil || admins != 1 {
+			t.Errorf("CountAdmins = %d, %v, want 1", admins, err)
+		}
+
+		createUser(t, store, "bob")
+		users, err := store.GetUsers(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 2 {
+			t.Errorf("GetUsers returned %d users, want 2", len(users))
+		}
+
+		if err := store.DeleteUsers(ctx); err != nil {
+			t.Fatal(err)
+		}
+		users, err = store.GetUsers(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 0 {
+			t.Errorf("GetUsers after DeleteUsers returned %d users", len(users))
+		}
+	})
+
+	t.Run("feeds and follows", func(t *testing.T) {
+		ctx := context.Background()
+		store := newStore(t)
+		alice := createUser(t, store, "alice")
+		bob := createUser(t, store, "bob")
+		feed := createFeed(t, store, alice, "Blog", "https://example.com/feed.xml")
+
+		byURL, err := store.GetFeedByUrl(ctx, feed.Url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if byURL.ID != feed.ID || byURL.LastFetchedAt.Valid {
+			t.Errorf("GetFeedByUrl returned %+v", byURL)
+		}
+
+		follow := followFeed(t, store, bob, feed)
+		if follow.FeedName != "Blog" || follow.UserName != "bob" {
+			t.Errorf("CreateFeedFollow returned %+v", follow)
+		}
+		if _, err := store.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+			ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: bob.ID, FeedID: feed.ID,
+		}); !IsUniqueViolation(err) {
+			t.Errorf("duplicate follow returned %v, want a unique violation", err)
+		}
+		if _, err := store.CreateFeed(ctx, database.CreateFeedParams{
+			ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Name: "Copy", Url: feed.Url, UserID: bob.ID,
+		}); !IsUniqueViolation(err) {
+			t.Errorf("duplicate feed url returned %v, want a unique violation", err)
+		}
+
+		follows, err := store.GetFeedFollowsForUser(ctx, bob.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(follows) != 1 || follows[0].FeedName != "Blog" || follows[0].FeedUrl != feed.Url {
+			t.Errorf("GetFeedFollowsForUser returned %+v", follows)
+		}
+
+		followers, err := store.GetFollowerIDsForFeed(ctx, feed.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(followers) != 1 || followers[0] != bob.ID {
+			t.Errorf("GetFollowerIDsForFeed returned %v", followers)
+		}
+
+		// Fetch order puts never-fetched feeds first.
+		other := createFeed(t, store, alice, "News", "https://example.com/news.xml")
+		fetchedAt := time.Now()
+		if err := store.MarkFeedFetched(ctx, database.MarkFeedFetchedParams{
+			ID:            feed.ID,
+			UpdatedAt:     fetchedAt,
+			LastFetchedAt: sql.NullTime{Time: fetchedAt, Valid: true},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		next, err := store.GetNextFeedToFetch(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next.ID != other.ID {
+			t.Errorf("GetNextFeedToFetch returned %s, want %s", next.Name, other.Name)
+		}
+
+		deleted, err := store.DeleteFeedFollow(ctx, database.DeleteFeedFollowParams{
+			Url:  feed.Url,
+			Name: bob.Name,
+		})
+		if err != nil || deleted != 1 {
+			t.Fatalf("DeleteFeedFollow = %d, %v, want 1 row", deleted, err)
+		}
+		if deleted, err := store.DeleteFeedFollow(ctx, database.DeleteFeedFollowParams{
+			Url:  feed.Url,
+			Name: bob.Name,
+		}); err != nil || deleted != 0 {
+			t.Errorf("second DeleteFeedFollow = %d, %v, want 0 rows", deleted, err)
+		}
+		follows, err = store.GetFeedFollowsForUser(ctx, bob.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(follows) != 0 {
+			t.Errorf("follow survived DeleteFeedFollow: %+v", follows)
+		}
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		ctx := context.Background()
+		store := newStore(t)
+		alice := createUser(t, store, "alice")
+		blog := createFeed(t, store, alice, "Blog", "https://example.com/blog.xml")
+		news := createFeed(t, store, alice, "News", "https://example.com/news.xml")
+		followFeed(t, store, alice, blog)
+		followFeed(t, store, alice, news)
+
+		blogFollow, err := store.GetFeedFollowForUserByUrl(ctx, database.GetFeedFollowForUserByUrlParams{
+			UserID: alice.ID,
+			Url:    blog.Url,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		addTag(t, store, blogFollow.ID, "tech")
+		addTag(t, store, blogFollow.ID, "tech")
+		addTag(t, store, blogFollow.ID, "daily")
+
+		rows, err := store.GetTaggedFeedFollowsForUser(ctx, alice.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// daily/Blog, tech/Blog, then the untagged News follow last.
+		if len(rows) != 3 || rows[0].TagName.String != "daily" || rows[1].TagName.String != "tech" || rows[2].TagName.Valid {
+			t.Errorf("GetTaggedFeedFollowsForUser returned %+v", rows)
+		}
+
+		renamed, err := store.RenameTagForUser(ctx, database.RenameTagForUserParams{
+			NewName:   "daily",
+			UpdatedAt: time.Now(),
+			OldName:   "tech",
+			UserID:    alice.ID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if renamed != 0 {
+			t.Errorf("rename onto an existing tag renamed %d rows, want 0", renamed)
+		}
+
+		removed, err := store.RemoveFeedFollowTag(ctx, database.RemoveFeedFollowTagParams{
+			FeedFollowID: blogFollow.ID,
+			Name:         "tech",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if removed != 1 {
+			t.Errorf("RemoveFeedFollowTag removed %d rows, want 1", removed)
+		}
+
+		deleted, err := store.DeleteTagForUser(ctx, database.DeleteTagForUserParams{
+			UserID: alice.ID,
+			Name:   "daily",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deleted != 1 {
+			t.Errorf("DeleteTagForUser deleted %d rows, want 1", deleted)
+		}
+	})
+
+	t.Run("posts, rules and state", func(t *testing.T) {
+		ctx := context.Background()
+		store := newStore(t)
+		alice := createUser(t, store, "alice")
+		feed := createFeed(t, store, alice, "Blog", "https://example.com/blog.xml")
+		follow := followFeed(t, store, alice, feed)
+		addTag(t, store, follow.ID, "tech")
+
+		older := createPost(t, store, feed, "Older", "https://example.com/1", time.Now().Add(-time.Hour))
+		newer := createPost(t, store, feed, "Newer", "https://example.com/2", time.Now())
+
+		// Duplicate URLs are skipped rather than failing.
+		_, err := store.CreatePost(ctx, database.CreatePostParams{
+			ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(),
+			Title: "Dup", Url: older.Url, FeedID: feed.ID,
+		})
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("duplicate CreatePost returned %v, want sql.ErrNoRows", err)
+		}
+
+		posts, err := store.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: alice.ID, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(posts) != 2 || posts[0].ID != newer.ID || posts[0].FeedName != "Blog" {
+			t.Fatalf("GetPostsForUser returned %+v", posts)
+		}
+
+		tagged, err := store.GetPostsForUserByTag(ctx, database.GetPostsForUserByTagParams{
+			UserID: alice.ID, Name: "tech", Limit: 1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tagged) != 1 || tagged[0].ID != newer.ID {
+			t.Errorf("GetPostsForUserByTag returned %+v", tagged)
+		}
+		tagged, err = store.GetPostsForUserByTag(ctx, database.GetPostsForUserByTagParams{
+			UserID: alice.ID, Name: "tech", Limit: 1, Offset: 1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tagged) != 1 || tagged[0].ID != older.ID {
+			t.Errorf("GetPostsForUserByTag with an offset returned %+v", tagged)
+		}
+
+		single, err := store.GetPostWithStateForUser(ctx, database.GetPostWithStateForUserParams{
+			UserID: alice.ID, Number: older.Number,
+		})
+		if err != nil || single.ID != older.ID || single.FeedName != "Blog" || single.ReadAt.Valid {
+			t.Errorf("GetPostWithStateForUser returned %+v, %v", single, err)
+		}
+
+		rule, err := store.CreateFilterRule(ctx, database.CreateFilterRuleParams{
+			ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(),
+			UserID: alice.ID, FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+			Field: "title", MatchType: "substring", Pattern: "older", Action: "hide",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules, err := store.GetFilterRulesForUser(ctx, alice.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rules) != 1 || rules[0].FeedID.UUID != feed.ID {
+			t.Errorf("GetFilterRulesForUser returned %+v", rules)
+		}
+
+		// State merges: rules only fill in what isn't set yet.
+		readAt := time.Now()
+		byRule := uuid.NullUUID{UUID: rule.ID, Valid: true}
+		applyState(t, store, database.ApplyPostStateParams{
+			UserID: alice.ID, PostID: older.ID, HiddenByRule: byRule,
+		})
+		applyState(t, store, database.ApplyPostStateParams{
+			UserID: alice.ID, PostID: newer.ID, HighlightedByRule: byRule,
+			ReadAt: sql.NullTime{Time: readAt, Valid: true}, ReadByRule: byRule,
+		})
+		applyState(t, store, database.ApplyPostStateParams{
+			UserID: alice.ID, PostID: newer.ID,
+			StarredAt: sql.NullTime{Time: readAt, Valid: true}, StarredByRule: byRule,
+		})
+
+		posts, err = store.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: alice.ID, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(posts) != 1 || posts[0].ID != newer.ID {
+			t.Fatalf("hidden post still listed: %+v", posts)
+		}
+		if !posts[0].Highlighted || !posts[0].ReadAt.Valid || !posts[0].StarredAt.Valid {
+			t.Errorf("post state not merged: %+v", posts[0])
+		}
+		if _, err := store.GetPostWithStateForUser(ctx, database.GetPostWithStateForUserParams{
+			UserID: alice.ID, Number: older.Number,
+		}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetPostWithStateForUser on a hidden post returned %v", err)
+		}
+
+		// Posts are numbered in insertion order, and the Set queries can
+		// clear state again.
+		if older.Number != 1 || newer.Number != 2 {
+			t.Errorf("post numbers = %d, %d, want 1, 2", older.Number, newer.Number)
+		}
+		byNumber, err := store.GetPostForUserByNumber(ctx, database.GetPostForUserByNumberParams{
+			UserID: alice.ID, Number: newer.Number,
+		})
+		if err != nil || byNumber.ID != newer.ID {
+			t.Errorf("GetPostForUserByNumber returned %+v, %v", byNumber, err)
+		}
+		if err := store.SetPostRead(ctx, database.SetPostReadParams{
+			ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: alice.ID, PostID: newer.ID,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SetPostStarred(ctx, database.SetPostStarredParams{
+			ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: alice.ID, PostID: newer.ID,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		posts, err = store.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: alice.ID, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if posts[0].ReadAt.Valid || posts[0].StarredAt.Valid || !posts[0].Highlighted {
+			t.Errorf("post state not cleared: %+v", posts[0])
+		}
+
+		// Setting state by hand takes it from the rule, so reverting the rule
+		// leaves it be.
+		setByRule, err := store.GetPostStatesSetByRule(ctx, byRule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(setByRule) != 2 {
+			t.Fatalf("GetPostStatesSetByRule returned %+v", setByRule)
+		}
+		for _, state := range setByRule {
+			if state.PostID == newer.ID && (state.ReadByRule.Valid || state.StarredByRule.Valid || state.HighlightedByRule != byRule) {
+				t.Errorf("state set by hand still credited to the rule: %+v", state)
+			}
+			if state.PostID == older.ID && (state.HiddenByRule != byRule || state.Title != "Older") {
+				t.Errorf("GetPostStatesSetByRule returned %+v for the hidden post", state)
+			}
+		}
+		if err := store.SetPostRead(ctx, database.SetPostReadParams{
+			ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: alice.ID, PostID: older.ID,
+			ReadAt: sql.NullTime{Time: time.Now(), Valid: true},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.RevertRuleStates(ctx, database.RevertRuleStatesParams{UpdatedAt: time.Now(), RuleID: byRule}); err != nil {
+			t.Fatal(err)
+		}
+		posts, err = store.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: alice.ID, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(posts) != 2 || posts[0].Highlighted || posts[1].Highlighted || !posts[1].ReadAt.Valid {
+			t.Errorf("posts after reverting the rule = %+v", posts)
+		}
+		applyState(t, store, database.ApplyPostStateParams{
+			UserID: alice.ID, PostID: older.ID, HiddenByRule: byRule,
+		})
+
+		byFeed, err := store.GetPostsForUserByFeed(ctx, database.GetPostsForUserByFeedParams{
+			UserID: alice.ID, FeedID: feed.ID, Limit: 10,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(byFeed) != 1 || byFeed[0].ID != newer.ID {
+			t.Errorf("GetPostsForUserByFeed returned %+v", byFeed)
+		}
+
+		// Hidden and read posts don't count as unread.
+		counts, err := store.GetFollowedFeedsWithUnreadCounts(ctx, alice.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(counts) != 1 || counts[0].ID != feed.ID || counts[0].Unread != 1 {
+			t.Errorf("GetFollowedFeedsWithUnreadCounts returned %+v", counts)
+		}
+		if err := store.SetPostRead(ctx, database.SetPostReadParams{
+			ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: alice.ID, PostID: newer.ID,
+			ReadAt: sql.NullTime{Time: time.Now(), Valid: true},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		counts, err = store.GetFollowedFeedsWithUnreadCounts(ctx, alice.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(counts) != 1 || counts[0].Unread != 0 {
+			t.Errorf("unread count after reading = %+v", counts)
+		}
+
+		deleted, err := store.DeleteFilterRuleForUser(ctx, database.DeleteFilterRuleForUserParams{
+			ID: rule.ID, UserID: alice.ID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deleted != 1 {
+			t.Errorf("DeleteFilterRuleForUser deleted %d rows, want 1", deleted)
+		}
+	})
+
+	t.Run("post and feed numbers", func(t *testing.T) {
+		ctx := context.Background()
+		store := newStore(t)
+		alice := createUser(t, store, "alice")
+		blog := createFeed(t, store, alice, "Blog", "https://example.com/blog.xml")
+		news := createFeed(t, store, alice, "News", "https://example.com/news.xml")
+		followFeed(t, store, alice, blog)
+		followFeed(t, store, alice, news)
+		if blog.Number != 1 || news.Number != 2 {
+			t.Errorf("feed numbers = %d, %d, want 1, 2", blog.Number, news.Number)
+		}
+		if feeds, err := store.GetFollowedFeedsForUser(ctx, alice.ID); err != nil || len(feeds) != 2 || feeds[1].ID != news.ID {
+			t.Errorf("GetFollowedFeedsForUser = %+v, %v", feeds, err)
+		}
+
+		first := createPost(t, store, blog, "First", "https://example.com/1", time.Now())
+		second := createPost(t, store, news, "Second", "https://example.com/2", time.Now())
+		third := createPost(t, store, blog, "Third", "https://example.com/3", time.Now())
+
+		after, err := store.GetPostsForUserAfterNumber(ctx, database.GetPostsForUserAfterNumberParams{
+			UserID: alice.ID, Number: first.Number, Limit: 1,
+		})
+		if err != nil || len(after) != 1 || after[0].ID != second.ID || after[0].FeedName != "News" {
+			t.Errorf("GetPostsForUserAfterNumber = %+v, %v", after, err)
+		}
+		before, err := store.GetPostsForUserBeforeNumber(ctx, database.GetPostsForUserBeforeNumberParams{
+			UserID: alice.ID, Number: third.Number, Limit: 10,
+		})
+		if err != nil || len(before) != 2 || before[0].ID != second.ID || before[1].ID != first.ID {
+			t.Errorf("GetPostsForUserBeforeNumber = %+v, %v", before, err)
+		}
+
+		applyState(t, store, database.ApplyPostStateParams{
+			UserID: alice.ID, PostID: first.ID,
+			ReadAt:    sql.NullTime{Time: time.Now(), Valid: true},
+			StarredAt: sql.NullTime{Time: time.Now(), Valid: true},
+		})
+		applyState(t, store, database.ApplyPostStateParams{
+			UserID: alice.ID, PostID: third.ID, HiddenByRule: uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		})
+		if count, err := store.CountPostsForUser(ctx, alice.ID); err != nil || count != 2 {
+			t.Errorf("CountPostsForUser = %d, %v, want 2", count, err)
+		}
+		if unread, err := store.GetUnreadPostNumbersForUser(ctx, alice.ID); err != nil || !slices.Equal(unread, []int64{second.Number}) {
+			t.Errorf("GetUnreadPostNumbersForUser = %v, %v", unread, err)
+		}
+		if starred, err := store.GetStarredPostNumbersForUser(ctx, alice.ID); err != nil || !slices.Equal(starred, []int64{first.Number}) {
+			t.Errorf("GetStarredPostNumbersForUser = %v, %v", starred, err)
+		}
+
+		unread, err := store.GetUnreadPostsForUserBefore(ctx, database.GetUnreadPostsForUserBeforeParams{
+			UserID: alice.ID, CreatedAt: time.Now().Add(time.Minute),
+		})
+		if err != nil || len(unread) != 1 || unread[0].ID != second.ID || unread[0].FeedID != news.ID {
+			t.Errorf("GetUnreadPostsForUserBefore = %+v, %v", unread, err)
+		}
+		if unread, err := store.GetUnreadPostsForUserBefore(ctx, database.GetUnreadPostsForUserBeforeParams{
+			UserID: alice.ID, CreatedAt: time.Now().Add(-time.Minute),
+		}); err != nil || len(unread) != 0 {
+			t.Errorf("GetUnreadPostsForUserBefore an earlier time = %+v, %v", unread, err)
+		}
+	})
+
+	// Scrapes save their posts in concurrent transactions, which must not
+	// hand out the same number twice.
+	t.Run("concurrent scrapes", func(t *testing.T) {
+		ctx := context.Background()
+		store := newStore(t)
+		alice := createUser(t, store, "alice")
+		const feeds, postsPerFeed = 4, 10
+
+		var wg sync.WaitGroup
+		errs := make(chan error, feeds)
+		for i := range feeds {
+			feed := createFeed(t, store, alice, fmt.Sprintf("Feed %d", i), fmt.Sprintf("https://example.com/%d.xml", i))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- store.InTx(ctx, func(tx Store) error {
+					if err := tx.LockPostNumbers(ctx); err != nil {
+						return err
+					}
+					for j := range postsPerFeed {
+						_, err := tx.CreatePost(ctx, database.CreatePostParams{
+							ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(),
+							Title: "Post", Url: fmt.Sprintf("https://example.com/%d/%d", i, j), FeedID: feed.ID,
+						})
+						if err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("concurrent scrape: %v", err)
+			}
+		}
+
+		var numbers []int64
+		for i := range feeds {
+			feed, err := store.GetFeedByUrl(ctx, fmt.Sprintf("https://example.com/%d.xml", i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			posts, err := store.GetPostsForFeed(ctx, feed.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, post := range posts {
+				numbers = append(numbers, post.Number)
+			}
+		}
+		slices.Sort(numbers)
+		for i, number := range numbers {
+			if number != int64(i+1) {
+				t.Fatalf("post numbers = %v, want 1 to %d", numbers, feeds*postsPerFeed)
+			}
+		}
+		if len(numbers) != feeds*postsPerFeed {
+			t.Errorf("saved %d posts, want %d", len(numbers), feeds*postsPerFeed)
+		}
+	})
+
+	t.Run("concurrent feed adds", func(t *testing.T) {
+		ctx := context.Background()
+		store := newStore(t)
+		alice := createUser(t, store, "alice")
+		const feeds = 8
+
+		var wg sync.WaitGroup
+		errs := make(chan error, feeds)
+		for i := range feeds {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- store.InTx(ctx, func(tx Store) error {
+					if err := tx.LockFeedNumbers(ctx); err != nil {
+						return err
+					}
+					_, err := tx.CreateFeed(ctx, database.CreateFeedParams{
+						ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(),
+						Name: fmt.Sprintf("Feed %d", i), Url: fmt.Sprintf("https://example.com/%d.xml", i), UserID: alice.ID,
+					})
+					return err
+				})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("concurrent feed add: %v", err)
+			}
+		}
+
+		all, err := store.GetFeeds(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var numbers []int64
+		for _, feed := range all {
+			numbers = append(numbers, feed.Number)
+		}
+		slices.Sort(numbers)
+		if want := []int64{1, 2, 3, 4, 5, 6, 7, 8}; !slices.Equal(numbers, want) {
+			t.Errorf("feed numbers = %v, want %v", numbers, want)
+		}
+	})
+
+	t.Run("passwords and api tokens", func(t *testing.T) {
+		ctx := context.Background()
+		store := newStore(t)
+		alice := createUser(t, store, "alice")
+		if alice.PasswordHash.Valid {
+			t.Errorf("new user has a password hash: %+v", alice)
+		}
+
+		hash := sql.NullString{String: "$argon2id$hash", Valid: true}
+		if err := store.SetUserPassword(ctx, database.SetUserPasswordParams{
+			ID: alice.ID, PasswordHash: hash, UpdatedAt: time.Now(),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := store.GetUser(ctx, "alice"); err != nil || got.PasswordHash != hash {
+			t.Errorf("GetUser after SetUserPassword = %+v, %v", got, err)
+		}
+
+		token, err := store.CreateAPIToken(ctx, database.CreateAPITokenParams{
+			ID: uuid.New(), CreatedAt: time.Now(), UserID: alice.ID, Name: "ci", TokenHash: "abc",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.CreateAPIToken(ctx, database.CreateAPITokenParams{
+			ID: uuid.New(), CreatedAt: time.Now(), UserID: alice.ID, Name: "ci", TokenHash: "def",
+		}); !IsUniqueViolation(err) {
+			t.Errorf("duplicate token name returned %v, want a unique violation", err)
+		}
+
+		usedAt := time.Now()
+		if err := store.MarkAPITokenUsed(ctx, database.MarkAPITokenUsedParams{
+			ID: token.ID, LastUsedAt: sql.NullTime{Time: usedAt, Valid: true},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := store.GetAPITokenByHash(ctx, "abc")
+		if err != nil || got.ID != token.ID || !got.LastUsedAt.Valid {
+			t.Errorf("GetAPITokenByHash = %+v, %v", got, err)
+		}
+		if tokens, err := store.GetAPITokensForUser(ctx, alice.ID); err != nil || len(tokens) != 1 {
+			t.Errorf("GetAPITokensForUser = %+v, %v", tokens, err)
+		}
+
+		removed, err := store.DeleteAPIToken(ctx, database.DeleteAPITokenParams{UserID: alice.ID, Name: "ci"})
+		if err != nil || removed != 1 {
+			t.Errorf("DeleteAPIToken = %d, %v", removed, err)
+		}
+		if _, err := store.GetAPITokenByHash(ctx, "abc"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetAPITokenByHash after delete returned %v, want sql.ErrNoRows", err)
+		}
+	})
+
+	t.Run("sessions", func(t *testing.T) {
+		ctx := context.Background()
+		store := newStore(t)
+		alice := createUser(t, store, "alice")
+
+		now := time.Now()
+		for hash, expiresAt := range map[string]time.Time{"live": now.Add(time.Hour), "stale": now.Add(-time.Hour)} {
+			if err := store.CreateSession(ctx, database.CreateSessionParams{
+				ID: uuid.New(), CreatedAt: now, ExpiresAt: expiresAt, UserID: alice.ID, TokenHash: hash,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, err := store.GetSessionUser(ctx, database.GetSessionUserParams{TokenHash: "live", ExpiresAt: now})
+		if err != nil || got.ID != alice.ID {
+			t.Errorf("GetSessionUser = %+v, %v", got, err)
+		}
+		if _, err := store.GetSessionUser(ctx, database.GetSessionUserParams{TokenHash: "stale", ExpiresAt: now}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetSessionUser for an expired session returned %v, want sql.ErrNoRows", err)
+		}
+
+		if err := store.DeleteExpiredSessions(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.GetSessionUser(ctx, database.GetSessionUserParams{TokenHash: "stale", ExpiresAt: now.Add(-2 * time.Hour)}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("expired session survived DeleteExpiredSessions: %v", err)
+		}
+		if err := store.DeleteSession(ctx, "live"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.GetSessionUser(ctx, database.GetSessionUserParams{TokenHash: "live", ExpiresAt: now}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetSessionUser after DeleteSession returned %v, want sql.ErrNoRows", err)
+		}
+	})
+
+	t.Run("feed changes", func(t *testing.T) {
+		ctx := context.Background()
+		store := newStore(t)
+		alice := createUser(t, store, "alice")
+		blog := createFeed(t, store, alice, "Blog", "https://example.com/blog.xml")
+		news := createFeed(t, store, alice, "News", "https://example.com/news.xml")
+
+		if err := store.SetFeedPaused(ctx, database.SetFeedPausedParams{ID: blog.ID, Paused: true, UpdatedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+		next, err := store.GetNextFeedToFetch(ctx)
+		if err != nil || next.ID != news.ID {
+			t.Errorf("GetNextFeedToFetch = %v, %v, want the unpaused feed", next.Name, err)
+		}
+		if err := store.SetFeedPaused(ctx, database.SetFeedPausedParams{ID: news.ID, Paused: true, UpdatedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.GetNextFeedToFetch(ctx); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetNextFeedToFetch with every feed paused returned %v, want sql.ErrNoRows", err)
+		}
+
+		if err := store.RenameFeed(ctx, database.RenameFeedParams{ID: blog.ID, Name: "Journal", UpdatedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SetFeedUrl(ctx, database.SetFeedUrlParams{ID: blog.ID, Url: news.Url, UpdatedAt: time.Now()}); !IsUniqueViolation(err) {
+			t.Errorf("moving a feed to a taken URL returned %v, want a unique violation", err)
+		}
+		if err := store.SetFeedUrl(ctx, database.SetFeedUrlParams{ID: blog.ID, Url: "https://example.com/journal.xml", UpdatedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := store.GetFeedByUrl(ctx, "https://example.com/journal.xml")
+		if err != nil || got.ID != blog.ID || got.Name != "Journal" || !got.Paused {
+			t.Errorf("GetFeedByUrl after changes = %+v, %v", got, err)
+		}
+
+		if err := store.DeleteFeed(ctx, news.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.GetFeedById(ctx, news.ID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetFeedById after DeleteFeed returned %v, want sql.ErrNoRows", err)
+		}
+	})
+
+	t.Run("user management", func(t *testing.T) {
+		ctx := context.Background()
+		store := newStore(t)
+		alice := createUser(t, store, "alice")
+		bob := createUser(t, store, "bob")
+		carol := createUser(t, store, "carol")
+		shared := createFeed(t, store, bob, "Shared", "https://example.com/shared.xml")
+		private := createFeed(t, store, bob, "Private", "https://example.com/private.xml")
+		followFeed(t, store, bob, shared)
+		followFeed(t, store, bob, private)
+		followFeed(t, store, carol, shared)
+		followFeed(t, store, alice, shared)
+
+		stats, err := store.GetUserStats(ctx, bob.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.FeedsOwned != 2 || stats.Follows != 2 || stats.PostsRead != 0 || stats.ApiTokens != 0 {
+			t.Errorf("GetUserStats = %+v, want 2 feeds owned and 2 follows", stats)
+		}
+
+		owned, err := store.GetFeedsOwnedByUser(ctx, bob.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(owned) != 2 || owned[0].Name != "Private" || owned[0].OtherFollowers != 0 || owned[1].OtherFollowers != 2 {
+			t.Errorf("GetFeedsOwnedByUser = %+v, want Private with 0 other followers and Shared with 2", owned)
+		}
+
+		oldest, err := store.GetOldestOtherFollower(ctx, database.GetOldestOtherFollowerParams{FeedID: shared.ID, UserID: bob.ID})
+		if err != nil || oldest != carol.ID {
+			t.Errorf("GetOldestOtherFollower = %v, %v, want carol", oldest, err)
+		}
+		if _, err := store.GetOldestOtherFollower(ctx, database.GetOldestOtherFollowerParams{FeedID: private.ID, UserID: bob.ID}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetOldestOtherFollower for an unshared feed returned %v, want sql.ErrNoRows", err)
+		}
+
+		if err := store.SetFeedOwner(ctx, database.SetFeedOwnerParams{ID: shared.ID, UserID: carol.ID, UpdatedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.DeleteUser(ctx, bob.ID); err != nil {
+			t.Fatal(err)
+		}
+		feeds, err := store.GetFeeds(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(feeds) != 1 || feeds[0].ID != shared.ID || feeds[0].UserID != carol.ID {
+			t.Errorf("feeds after DeleteUser = %+v, want only the transferred one", feeds)
+		}
+
+		if err := store.RenameUser(ctx, database.RenameUserParams{ID: alice.ID, Name: "carol", UpdatedAt: time.Now()}); !IsUniqueViolation(err) {
+			t.Errorf("renaming to a taken name returned %v, want a unique violation", err)
+		}
+		if err := store.RenameUser(ctx, database.RenameUserParams{ID: alice.ID, Name: "alicia", UpdatedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := store.GetUser(ctx, "alicia"); err != nil || got.ID != alice.ID {
+			t.Errorf("GetUser after RenameUser = %v, %v", got.ID, err)
+		}
+	})
+
+	t.Run("transactions", func(t *testing.T) {
+		ctx := context.Background()
+		store := newStore(t)
+		errFailed := errors.New("failed")
+
+		// A failing function rolls back everything it wrote, nested calls
+		// included.
+		err := store.InTx(ctx, func(tx Store) error {
+			alice := createUser(t, tx, "alice")
+			createFeed(t, tx, alice, "Blog", "https://example.com/blog.xml")
+			return tx.InTx(ctx, func(nested Store) error {
+				createUser(t, nested, "bob")
+				return errFailed
+			})
+		})
+		if !errors.Is(err, errFailed) {
+			t.Errorf("InTx returned %v, want the function's error", err)
+		}
+		users, err := store.GetUsers(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		feeds, err := store.GetFeeds(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 0 || len(feeds) != 0 {
+			t.Errorf("rolled back transaction left %d users and %d feeds", len(users), len(feeds))
+		}
+
+		// A failed statement rolls back too, so nothing is left half done.
+		err = store.InTx(ctx, func(tx Store) error {
+			alice := createUser(t, tx, "alice")
+			_, err := tx.CreateUser(ctx, database.CreateUserParams{
+				ID: alice.ID, Name: "alicia", CreatedAt: time.Now(), UpdatedAt: time.Now(), Role: "member",
+			})
+			return err
+		})
+		if !IsUniqueViolation(err) {
+			t.Errorf("InTx returned %v, want a unique violation", err)
+		}
+		if _, err := store.GetUser(ctx, "alice"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetUser after a failed transaction returned %v, want sql.ErrNoRows", err)
+		}
+
+		if err := store.InTx(ctx, func(tx Store) error {
+			createUser(t, tx, "alice")
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.GetUser(ctx, "alice"); err != nil {
+			t.Errorf("GetUser after a committed transaction returned %v", err)
+		}
+	})
+
+	t.Run("cascading deletes", func(t *testing.T) {
+		ctx := context.Background()
+		store := newStore(t)
+		alice := createUser(t, store, "alice")
+		feed := createFeed(t, store, alice, "Blog", "https://example.com/blog.xml")
+		followFeed(t, store, alice, feed)
+		createPost(t, store, feed, "Post", "https://example.com/1", time.Now())
+
+		if err := store.DeleteUsers(ctx); err != nil {
+			t.Fatal(err)
+		}
+		feeds, err := store.GetFeeds(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(feeds) != 0 {
+			t.Errorf("feeds survived deleting their owner: %+v", feeds)
+		}
+	})
+}
+
+func createUser(t *testing.T, store Store, name string) database.User {
+	t.Helper()
+	user, err := store.CreateUser(context.Background(), database.CreateUserParams{
+		ID:        uuid.New(),
+		Name:      name,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Role:      "member",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func createFeed(t *testing.T, store Store, owner database.User, name, url string) database.Feed {
+	t.Helper()
+	feed, err := store.CreateFeed(context.Background(), database.CreateFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name:      name,
+		Url:       url,
+		UserID:    owner.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return feed
+}
+
+func followFeed(t *testing.T, store Store, user database.User, feed database.Feed) database.CreateFeedFollowRow {
+	t.Helper()
+	follow, err := store.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return follow
+}
+
+func addTag(t *testing.T, store Store, followID uuid.UUID, name string) {
+	t.Helper()
+	err := store.AddFeedFollowTag(context.Background(), database.AddFeedFollowTagParams{
+		ID:           uuid.New(),
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+		FeedFollowID: followID,
+		Name:         name,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func createPost(t *testing.T, store Store, feed database.Feed, title, url string, publishedAt time.Time) database.Post {
+	t.Helper()
+	post, err := store.CreatePost(context.Background(), database.CreatePostParams{
+		ID:          uuid.New(),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		Title:       title,
+		Url:         url,
+		PublishedAt: sql.NullTime{Time: publishedAt, Valid: true},
+		FeedID:      feed.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return post
+}
+
+func applyState(t *testing.T, store Store, params database.ApplyPostStateParams) {
+	t.Helper()
+	params.ID = uuid.New()
+	params.CreatedAt = time.Now()
+	params.UpdatedAt = time.Now()
+	if err := store.ApplyPostState(context.Background(), params); err != nil {
+		t.Fatal(err)
+	}
+}
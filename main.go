@@ -1,71 +1,113 @@
 package main
 
 import (
-	"database/sql"
-	"log"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 
-	_ "github.com/lib/pq"
 	"github.com/praneeth-ayla/gator/internal/config"
-	"github.com/praneeth-ayla/gator/internal/database"
+	"github.com/praneeth-ayla/gator/internal/storage"
 )
 
-// state holds the application's global state, including database queries and configuration.
+// state holds the application's global state, including the data store and configuration.
 type state struct {
-	db  *database.Queries
+	db  storage.Store
 	cfg *config.Config
+	// session is the user given with --as when the shell was started, which
+	// every command run in it acts as.
+	session string
+}
+
+// skipsSchemaCheck lists commands that run regardless of the schema version.
+var skipsSchemaCheck = map[string]bool{
+	"migrate":    true,
+	"help":       true,
+	"completion": true,
+	"__complete": true,
+}
+
+// skipsDatabase lists commands that don't touch the database, so they run
+// without one and before a db_url has been set.
+var skipsDatabase = map[string]bool{
+	"config":     true,
+	"profile":    true,
+	"help":       true,
+	"completion": true,
+}
+
+// optionalDatabase lists commands that use the database when they can reach
+// it and carry on without it otherwise.
+var optionalDatabase = map[string]bool{
+	"__complete": true,
 }
 
 func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs the command given by args and returns the process exit status.
+// Returning instead of exiting lets deferred cleanup, like closing the
+// database, happen on every path.
+func run(args []string) int {
+	// Commands derive their contexts from this one; see commands.run.
+	ctx := context.Background()
+
+	// Initialize commands and register handlers.
+	cmds := newCommands()
+
+	// Without a command, start the interactive shell.
+	if len(args) == 0 {
+		args = []string{"shell"}
+	}
+	cmdName := cmds.commandName(args)
+
 	// Read application configuration.
-	cfg, err := config.Read()
-	if err != nil {
-		log.Fatalf("error reading config: %v", err)
+	cfg, err := config.Load(findFlag(args, "config"), findFlag(args, "profile"))
+	noDbURL := errors.Is(err, config.ErrNoDbURL)
+	if err != nil && !((skipsDatabase[cmdName] || optionalDatabase[cmdName]) && noDbURL) {
+		return fail(fmt.Errorf("error reading config: %w", err))
 	}
 
 	// Initialize program state.
 	programState := &state{
 		cfg: &cfg,
 	}
-
-	// Open database connection.
-	db, err := sql.Open("postgres", cfg.DbURL)
-	if err != nil {
-		log.Fatal("Error connecting to db:", err)
-	}
-
-	// Create database queries instance.
-	dbQueries := database.New(db)
-	programState.db = dbQueries
-
-	// Initialize commands and register handlers.
-	cmds := commands{
-		registeredCommands: make(map[string]func(*state, command) error),
+	if !skipsDatabase[cmdName] && !noDbURL {
+		// Open the data store selected by the db_url scheme.
+		db, err := storage.Open(cfg.DbURL)
+		if err != nil && !optionalDatabase[cmdName] {
+			return fail(fmt.Errorf("error connecting to db: %w", err))
+		}
+		if err == nil {
+			defer db.Close()
+			programState.db = db
+		}
 	}
 
-	cmds.register("login", handlerLogin)
-	cmds.register("register", handlerRegister)
-	cmds.register("reset", handlerReset)
-	cmds.register("users", handlerGetUsers)
-	cmds.register("agg", handlerAgg)
-	cmds.register("addfeed", middlewareLoggedIn(handlerAddFeed))
-	cmds.register("feeds", (handlerFeeds))
-	cmds.register("follow", middlewareLoggedIn(handlerFollow))
-	cmds.register("following", middlewareLoggedIn(handlerFollowing))
-	cmds.register("unfollow", middlewareLoggedIn(handlerUnfollow))
-
-	// Check for command-line arguments.
-	if len(os.Args) < 2 {
-		log.Fatal("Usage: cli  [args...]")
+	// Refuse to run commands against a schema that doesn't match this binary.
+	// Help and completion don't touch the database, and completion must stay
+	// silent when it can't reach it.
+	if programState.db != nil && cmdName != "" && !skipsSchemaCheck[cmdName] {
+		migrator, err := newMigrator(programState.db)
+		if err != nil {
+			return fail(fmt.Errorf("error loading migrations: %w", err))
+		}
+		if err := migrator.CheckCurrent(ctx); err != nil {
+			return fail(err)
+		}
 	}
-	cmdName := os.Args[1]
-	cmdArgs := os.Args[2:]
 
 	// Run the specified command.
-	err = cmds.run(programState, command{Name: cmdName, Args: cmdArgs})
-	if err != nil {
-		os.Exit(1)
-		log.Fatal(err)
+	if err := cmds.run(ctx, programState, args); err != nil {
+		return fail(err)
 	}
+	return 0
+}
 
+// fail prints err for the user and returns the exit status for its kind.
+func fail(err error) int {
+	err = classify(err)
+	fmt.Fprintf(os.Stderr, "gator: %v\n", err)
+	return exitCode(err)
 }
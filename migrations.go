@@ -0,0 +1,34 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/praneeth-ayla/gator/internal/migrate"
+	"github.com/praneeth-ayla/gator/internal/storage"
+)
+
+// schemaFS embeds the goose migrations for every backend so the binary can
+// migrate on its own. The two directories must hold the same versions.
+//
+//go:embed sql/schema/*.sql sql/schema_sqlite/*.sql
+var schemaFS embed.FS
+
+// schemaDirs maps each dialect to its migration directory.
+var schemaDirs = map[migrate.Dialect]string{
+	migrate.Postgres: "sql/schema",
+	migrate.SQLite:   "sql/schema_sqlite",
+}
+
+// newMigrator loads the embedded migrations matching the store's backend.
+func newMigrator(store storage.Store) (*migrate.Migrator, error) {
+	schema, err := fs.Sub(schemaFS, schemaDirs[store.Dialect()])
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := migrate.Load(schema)
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(store.DB(), store.Dialect(), migrations), nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/praneeth-ayla/gator/internal/auth"
+	"golang.org/x/term"
+)
+
+// readLine reads one line from stdin without its line ending, returning
+// io.EOF when stdin is closed before anything is read. It reads a byte at a
+// time so nothing past the line is consumed.
+func readLine() (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if n == 1 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+		}
+		if errors.Is(err, io.EOF) {
+			if len(line) == 0 {
+				return "", io.EOF
+			}
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSuffix(string(line), "\r"), nil
+}
+
+// confirm asks the user to confirm a destructive action, unless --yes was
+// given. Anything but y or yes, including no answer, declines.
+func confirm(cmd command, format string, args ...any) error {
+	if cmd.Bool("yes") {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, format+" [y/N] ", args...)
+	answer, err := readLine()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	if errors.Is(err, io.EOF) {
+		fmt.Fprintln(os.Stderr)
+	}
+	return newError(errUsage, "cancelled, pass --yes to skip the confirmation")
+}
+
+// readPassword prompts for a password on the terminal without echoing it.
+// When stdin isn't a terminal, as in scripts and tests, one line is read
+// from it instead.
+func readPassword(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt)
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return string(password), err
+	}
+
+	password, err := readLine()
+	if errors.Is(err, io.EOF) {
+		return "", newError(errUsage, "no password given on stdin")
+	}
+	return password, err
+}
+
+// readNewPassword prompts for a new password, twice on a terminal so typos
+// are caught, and returns its hash.
+func readNewPassword() (sql.NullString, error) {
+	password, err := readPassword("New password: ")
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		again, err := readPassword("Repeat password: ")
+		if err != nil {
+			return sql.NullString{}, err
+		}
+		if again != password {
+			return sql.NullString{}, newError(errUsage, "passwords don't match")
+		}
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return sql.NullString{}, newError(errUsage, "%v", err)
+	}
+	return sql.NullString{String: hash, Valid: true}, nil
+}
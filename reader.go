@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/praneeth-ayla/gator/internal/database"
+)
+
+// readerPostLimit caps how many posts the reader lists for one source.
+const readerPostLimit = 200
+
+// sourceKind says what a source in the reader's first pane lists.
+type sourceKind int
+
+const (
+	sourceAll sourceKind = iota
+	sourceTag
+	sourceFeed
+)
+
+// source is an entry in the reader's first pane: every followed feed, the
+// feeds carrying a tag, or a single feed.
+type source struct {
+	kind    sourceKind
+	name    string
+	feedIDs []uuid.UUID
+	unread  int64
+}
+
+// key identifies a source across reloads.
+func (src source) key() string {
+	return fmt.Sprintf("%d:%s", src.kind, src.name)
+}
+
+// label is how the source is listed, with its unread count.
+func (src source) label() string {
+	label := src.name
+	switch src.kind {
+	case sourceAll:
+		label = "All posts"
+	case sourceTag:
+		label = "#" + src.name
+	case sourceFeed:
+		label = "  " + src.name
+	}
+	if src.unread > 0 {
+		label += fmt.Sprintf(" (%d)", src.unread)
+	}
+	return label
+}
+
+// readerPost is a post listed in the reader.
+type readerPost struct {
+	id   uuid.UUID
+	view postView
+}
+
+// reader loads what the TUI shows and applies the changes made in it, using
+// the same queries as the CLI commands.
+type reader struct {
+	s    *state
+	user database.User
+}
+
+// loadSources lists every source with its unread count: all posts first,
+// then one per tag, then one per followed feed.
+func (r *reader) loadSources(ctx context.Context) ([]source, error) {
+	feeds, err := r.s.db.GetFollowedFeedsWithUnreadCounts(ctx, r.user.ID)
+	if err != nil {
+		return nil, err
+	}
+	tagged, err := r.s.db.GetTaggedFeedFollowsForUser(ctx, r.user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	all := source{kind: sourceAll}
+	byURL := make(map[string]database.GetFollowedFeedsWithUnreadCountsRow, len(feeds))
+	for _, feed := range feeds {
+		all.unread += feed.Unread
+		all.feedIDs = append(all.feedIDs, feed.ID)
+		byURL[feed.Url] = feed
+	}
+
+	// Tagged rows arrive ordered by tag name.
+	var tags []source
+	for _, row := range tagged {
+		if !row.TagName.Valid {
+			continue
+		}
+		if len(tags) == 0 || tags[len(tags)-1].name != row.TagName.String {
+			tags = append(tags, source{kind: sourceTag, name: row.TagName.String})
+		}
+		feed := byURL[row.FeedUrl]
+		tags[len(tags)-1].unread += feed.Unread
+		tags[len(tags)-1].feedIDs = append(tags[len(tags)-1].feedIDs, feed.ID)
+	}
+
+	sources := append([]source{all}, tags...)
+	for _, feed := range feeds {
+		sources = append(sources, source{
+			kind:    sourceFeed,
+			name:    feed.Name,
+			feedIDs: []uuid.UUID{feed.ID},
+			unread:  feed.Unread,
+		})
+	}
+	return sources, nil
+}
+
+// loadPosts lists the newest posts of a source, leaving out the ones the
+// user's filter rules hide, as browse does.
+func (r *reader) loadPosts(ctx context.Context, src source) ([]readerPost, error) {
+	var rows []database.GetPostsForUserRow
+	switch src.kind {
+	case sourceAll:
+		all, err := r.s.db.GetPostsForUser(ctx, database.GetPostsForUserParams{
+			UserID: r.user.ID,
+			Limit:  readerPostLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		rows = all
+	case sourceTag:
+		tagged, err := r.s.db.GetPostsForUserByTag(ctx, database.GetPostsForUserByTagParams{
+			UserID: r.user.ID,
+			Name:   src.name,
+			Limit:  readerPostLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range tagged {
+			rows = append(rows, database.GetPostsForUserRow(row))
+		}
+	case sourceFeed:
+		byFeed, err := r.s.db.GetPostsForUserByFeed(ctx, database.GetPostsForUserByFeedParams{
+			UserID: r.user.ID,
+			FeedID: src.feedIDs[0],
+			Limit:  readerPostLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range byFeed {
+			rows = append(rows, database.GetPostsForUserRow(row))
+		}
+	}
+
+	posts := make([]readerPost, 0, len(rows))
+	for _, row := range rows {
+		posts = append(posts, readerPost{id: row.ID, view: newPostView(row)})
+	}
+	return posts, nil
+}
+
+// setRead marks a post read or unread.
+func (r *reader) setRead(ctx context.Context, post *readerPost, read bool) error {
+	readAt, err := setPostRead(ctx, r.s, r.user.ID, post.id, read)
+	if err != nil {
+		return err
+	}
+	post.view.ReadAt = nullTime(readAt.Valid, readAt.Time)
+	return nil
+}
+
+// setStarred stars or unstars a post.
+func (r *reader) setStarred(ctx context.Context, post *readerPost, starred bool) error {
+	starredAt, err := setPostStarred(ctx, r.s, r.user.ID, post.id, starred)
+	if err != nil {
+		return err
+	}
+	post.view.StarredAt = nullTime(starredAt.Valid, starredAt.Time)
+	return nil
+}
+
+// refresh scrapes every feed in a source now and returns how many new posts
+// it saved. Feeds that fail are skipped and their errors returned together.
+func (r *reader) refresh(ctx context.Context, src source) (int, error) {
+	found := 0
+	var errs []error
+	for _, feedID := range src.feedIDs {
+		feed, err := r.s.db.GetFeedById(ctx, feedID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result, err := scrapeFeed(ctx, r.s, feed)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", feed.Name, err))
+			continue
+		}
+		found += result.NewPosts
+	}
+	return found, errors.Join(errs...)
+}
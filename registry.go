@@ -0,0 +1,435 @@
+package main
+
+import "flag"
+
+// newCommands creates the command registry with every command registered.
+func newCommands() *commands {
+	cmds := newCommandSet()
+	cmds.globalFlags = func(fs *flag.FlagSet) {
+		fs.String("output", "", "output format: text, json, ndjson, csv, table or template=<go template>")
+		fs.String("config", "", "config file (default $XDG_CONFIG_HOME/gator/config.json)")
+		fs.String("profile", "", "config profile to use for this run (default $GATOR_PROFILE or the saved one)")
+		fs.String("as", "", "user to act as without logging in (default $GATOR_USER or the logged-in one)")
+		fs.Duration("timeout", 0, "give up on the command after this long, e.g. 30s (default no limit)")
+	}
+	cmds.globalFlagCompletions = map[string]completer{
+		"output":  completeOutputFormats,
+		"profile": completeProfiles,
+		"as":      completeUsers,
+	}
+
+	cmds.register(&commandSpec{
+		Name:        "help",
+		Description: "Show the list of commands or help for one command",
+		Args:        []argSpec{{Name: "command", Optional: true, Variadic: true}},
+		Handler:     cmds.handlerHelp,
+	})
+	cmds.register(&commandSpec{
+		Name:        "completion",
+		Description: "Print a shell completion script for bash, zsh or fish",
+		Args:        []argSpec{{Name: "shell", Complete: completeShells}},
+		Handler:     handlerCompletion,
+	})
+	cmds.register(&commandSpec{
+		Name:        "shell",
+		Description: "Start an interactive shell (also what running gator alone does)",
+		Interactive: true,
+		Handler:     cmds.handlerShell,
+	})
+	cmds.register(&commandSpec{
+		Name:        "__complete",
+		Description: "Print completion candidates for the words typed so far",
+		Args:        []argSpec{{Name: "words", Optional: true, Variadic: true}},
+		RawArgs:     true,
+		Hidden:      true,
+		Handler:     cmds.handlerComplete,
+	})
+
+	// Users.
+	cmds.register(&commandSpec{
+		Name:        "register",
+		Description: "Create a user and log in as them",
+		Args:        []argSpec{{Name: "name"}},
+		Flags: func(fs *flag.FlagSet) {
+			fs.Bool("password", false, "set a password (always asked for when auth is required)")
+		},
+		Handler: handlerRegister,
+	})
+	cmds.register(&commandSpec{
+		Name:        "login",
+		Description: "Log in as an existing user, giving their password if they have one",
+		Args:        []argSpec{{Name: "name", Complete: completeUsers}},
+		Handler:     handlerLogin,
+	})
+	cmds.register(&commandSpec{
+		Name:        "passwd",
+		Description: "Set or change your password",
+		Handler:     middlewareLoggedIn(handlerPasswd),
+	})
+	cmds.register(&commandSpec{
+		Name:        "token",
+		Description: "List your API tokens",
+		Handler:     middlewareLoggedIn(handlerTokens),
+		Subcommands: []*commandSpec{
+			{Name: "list", Description: "List your API tokens", Handler: middlewareLoggedIn(handlerTokens)},
+			{
+				Name:        "create",
+				Description: "Create an API token for scripts, used through GATOR_TOKEN",
+				Args:        []argSpec{{Name: "name"}},
+				Handler:     middlewareLoggedIn(handlerTokenCreate),
+			},
+			{
+				Name:        "rm",
+				Description: "Revoke an API token",
+				Args:        []argSpec{{Name: "name", Complete: completeTokens}},
+				Handler:     middlewareLoggedIn(handlerTokenRemove),
+			},
+			{
+				Name:        "fever",
+				Description: "Set the password Fever apps log in with, kept as a token named fever",
+				Handler:     middlewareLoggedIn(handlerTokenFever),
+			},
+		},
+	})
+	cmds.register(&commandSpec{
+		Name:        "whoami",
+		Description: "Show the user commands act as and where that comes from",
+		Handler:     handlerWhoami,
+	})
+	cmds.register(&commandSpec{
+		Name:        "users",
+		Description: "List all users, marking the current one",
+		Handler:     handlerGetUsers,
+	})
+	cmds.register(&commandSpec{
+		Name:        "reset",
+		Description: "Delete every user and everything they own (admins only)",
+		Flags:       yesFlag,
+		Handler:     middlewareAdmin(handlerReset),
+	})
+	cmds.register(&commandSpec{
+		Name:        "user",
+		Description: "Manage other users (admins only)",
+		Subcommands: []*commandSpec{
+			{
+				Name:        "role",
+				Description: "Make a user an admin or a member",
+				Args:        []argSpec{{Name: "name", Complete: completeUsers}, {Name: "admin|member", Complete: completeRoles}},
+				Handler:     middlewareAdmin(handlerUserRole),
+			},
+			{
+				Name:        "passwd",
+				Description: "Set a user's password without knowing the old one",
+				Args:        []argSpec{{Name: "name", Complete: completeUsers}},
+				Flags:       yesFlag,
+				Handler:     middlewareAdmin(handlerUserPasswd),
+			},
+			{
+				Name:        "show",
+				Description: "Show a user's details and what they have stored",
+				Args:        []argSpec{{Name: "name", Complete: completeUsers}},
+				Handler:     middlewareAdmin(handlerUserShow),
+			},
+			{
+				Name:        "rename",
+				Description: "Change a user's name",
+				Args:        []argSpec{{Name: "name", Complete: completeUsers}, {Name: "new-name"}},
+				Handler:     middlewareAdmin(handlerUserRename),
+			},
+			{
+				Name:        "rm",
+				Description: "Delete a user, handing feeds others follow to another user",
+				Args:        []argSpec{{Name: "name", Complete: completeUsers}},
+				Flags: func(fs *flag.FlagSet) {
+					yesFlag(fs)
+					fs.String("to", "", "user who takes over every feed the removed user added")
+				},
+				FlagCompletions: map[string]completer{"to": completeUsers},
+				Handler:         middlewareAdmin(handlerUserRemove),
+			},
+		},
+	})
+
+	// Feeds and follows.
+	addFeed := &commandSpec{
+		Name:        "addfeed",
+		Description: "Add a feed and follow it",
+		Args:        []argSpec{{Name: "name"}, {Name: "url"}},
+		Handler:     middlewareLoggedIn(handlerAddFeed),
+	}
+	listFeeds := &commandSpec{
+		Name:        "feeds",
+		Description: "List every registered feed",
+		Handler:     handlerFeeds,
+	}
+	cmds.register(addFeed)
+	cmds.register(listFeeds)
+	cmds.register(&commandSpec{
+		Name:        "feed",
+		Description: "Manage feeds",
+		Subcommands: []*commandSpec{
+			{Name: "add", Description: addFeed.Description, Args: addFeed.Args, Handler: addFeed.Handler},
+			{Name: "list", Description: listFeeds.Description, Handler: listFeeds.Handler},
+			{
+				Name:        "rename",
+				Description: "Change the name a feed is shown with",
+				Args:        []argSpec{{Name: "url", Complete: completeFeedURLs}, {Name: "name"}},
+				Handler:     middlewareLoggedIn(handlerFeedRename),
+			},
+			{
+				Name:        "set-url",
+				Description: "Point a feed at a new URL, keeping its follows and posts",
+				Args:        []argSpec{{Name: "url", Complete: completeFeedURLs}, {Name: "new-url"}},
+				Handler:     middlewareLoggedIn(handlerFeedSetURL),
+			},
+			{
+				Name:        "rm",
+				Description: "Delete a feed with its posts and follows",
+				Args:        []argSpec{{Name: "url", Complete: completeFeedURLs}},
+				Flags:       yesFlag,
+				Handler:     middlewareLoggedIn(handlerFeedRemove),
+			},
+			{
+				Name:        "pause",
+				Description: "Stop fetching a feed until it's resumed",
+				Args:        []argSpec{{Name: "url", Complete: completeFeedURLs}},
+				Handler:     middlewareLoggedIn(handlerFeedPause),
+			},
+			{
+				Name:        "resume",
+				Description: "Fetch a paused feed again",
+				Args:        []argSpec{{Name: "url", Complete: completeFeedURLs}},
+				Handler:     middlewareLoggedIn(handlerFeedResume),
+			},
+			{
+				Name:        "transfer",
+				Description: "Hand a feed you added over to another user",
+				Args:        []argSpec{{Name: "url", Complete: completeFeedURLs}, {Name: "user", Complete: completeUsers}},
+				Handler:     middlewareLoggedIn(handlerFeedTransfer),
+			},
+		},
+	})
+	cmds.register(&commandSpec{
+		Name:        "follow",
+		Description: "Follow a feed by URL, adding it if nobody has yet",
+		Args:        []argSpec{{Name: "url", Complete: completeFeedURLs}},
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("name", "", "name for a feed that's added (default the feed's title)")
+		},
+		Handler: middlewareLoggedIn(handlerFollow),
+	})
+	cmds.register(&commandSpec{
+		Name:        "following",
+		Description: "List the feeds you follow",
+		Handler:     middlewareLoggedIn(handlerFollowing),
+	})
+	cmds.register(&commandSpec{
+		Name:        "unfollow",
+		Description: "Stop following a feed by URL, name, or number in following",
+		Args:        []argSpec{{Name: "feed", Complete: completeFollowedURLs}},
+		Handler:     middlewareLoggedIn(handlerUnfollow),
+	})
+
+	// Posts.
+	cmds.register(&commandSpec{
+		Name:        "agg",
+		Description: `Scrape feeds every interval (e.g. "1m"), or each feed once with "once"`,
+		Args:        []argSpec{{Name: "time_between_reqs|once"}},
+		Handler:     handlerAgg,
+	})
+	cmds.register(&commandSpec{
+		Name:        "browse",
+		Description: "Show the latest posts from the feeds you follow",
+		Args:        []argSpec{{Name: "limit", Optional: true}},
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("tag", "", "only show posts from follows with this tag")
+		},
+		FlagCompletions: map[string]completer{"tag": completeTags},
+		Handler:         middlewareLoggedIn(handlerBrowse),
+	})
+	cmds.register(&commandSpec{
+		Name:        "tui",
+		Description: "Read posts in a full-screen terminal reader",
+		Interactive: true,
+		Handler:     middlewareLoggedIn(handlerTUI),
+	})
+	cmds.register(&commandSpec{
+		Name:        "read",
+		Description: "Mark posts as read by the numbers shown in browse",
+		Args:        []argSpec{{Name: "post", Variadic: true}},
+		Handler:     middlewareLoggedIn(handlerRead),
+	})
+	cmds.register(&commandSpec{
+		Name:        "unread",
+		Description: "Mark posts as unread",
+		Args:        []argSpec{{Name: "post", Variadic: true}},
+		Handler:     middlewareLoggedIn(handlerUnread),
+	})
+	cmds.register(&commandSpec{
+		Name:        "star",
+		Description: "Star posts by the numbers shown in browse",
+		Args:        []argSpec{{Name: "post", Variadic: true}},
+		Handler:     middlewareLoggedIn(handlerStar),
+	})
+	cmds.register(&commandSpec{
+		Name:        "unstar",
+		Description: "Remove the star from posts",
+		Args:        []argSpec{{Name: "post", Variadic: true}},
+		Handler:     middlewareLoggedIn(handlerUnstar),
+	})
+
+	// Tags.
+	cmds.register(&commandSpec{
+		Name:        "tag",
+		Description: "Tag a followed feed",
+		Args: []argSpec{
+			{Name: "feed_url", Complete: completeFollowedURLs},
+			{Name: "tag", Variadic: true, Complete: completeTags},
+		},
+		Handler: middlewareLoggedIn(handlerTag),
+	})
+	cmds.register(&commandSpec{
+		Name:        "untag",
+		Description: "Remove a tag from a followed feed",
+		Args: []argSpec{
+			{Name: "feed_url", Complete: completeFollowedURLs},
+			{Name: "tag", Complete: completeTags},
+		},
+		Handler: middlewareLoggedIn(handlerUntag),
+	})
+	cmds.register(&commandSpec{
+		Name:        "tags",
+		Description: "List your follows grouped by tag",
+		Handler:     middlewareLoggedIn(handlerTags),
+		Subcommands: []*commandSpec{
+			{
+				Name:        "rename",
+				Description: "Rename a tag across all of your follows",
+				Args:        []argSpec{{Name: "old", Complete: completeTags}, {Name: "new"}},
+				Handler:     middlewareLoggedIn(handlerTagsRename),
+			},
+			{
+				Name:        "delete",
+				Description: "Remove a tag from all of your follows",
+				Args:        []argSpec{{Name: "tag", Complete: completeTags}},
+				Handler:     middlewareLoggedIn(handlerTagsDelete),
+			},
+		},
+	})
+
+	// Filter rules.
+	cmds.register(&commandSpec{
+		Name:        "rules",
+		Description: "List your filter rules",
+		Handler:     middlewareLoggedIn(handlerRules),
+		Subcommands: []*commandSpec{
+			{
+				Name: "add",
+				Description: "Add a rule: field is title, description, author or category; " +
+					"action is hide, mark-read, highlight or star",
+				Args: []argSpec{{Name: "field"}, {Name: "substring|regex"}, {Name: "pattern"}, {Name: "action"}},
+				Flags: func(fs *flag.FlagSet) {
+					fs.String("feed", "", "only apply the rule to the feed with this URL")
+				},
+				FlagCompletions: map[string]completer{"feed": completeFeedURLs},
+				Handler:         middlewareLoggedIn(handlerRulesAdd),
+			},
+			{
+				Name:        "rm",
+				Description: "Remove a rule by ID",
+				Args:        []argSpec{{Name: "rule_id"}},
+				Handler:     middlewareLoggedIn(handlerRulesRemove),
+			},
+		},
+	})
+
+	// Configuration.
+	cmds.register(&commandSpec{
+		Name:        "config",
+		Description: "List settings with their values and where they come from",
+		Handler:     handlerConfigList,
+		Subcommands: []*commandSpec{
+			{Name: "list", Description: "List settings with their values and where they come from", Handler: handlerConfigList},
+			{
+				Name:        "get",
+				Description: "Print the value of a setting",
+				Args:        []argSpec{{Name: "key", Complete: completeSettings}},
+				Handler:     handlerConfigGet,
+			},
+			{
+				Name:        "set",
+				Description: "Validate a value and save it in the config file",
+				Args:        []argSpec{{Name: "key", Complete: completeSettings}, {Name: "value"}},
+				Handler:     handlerConfigSet,
+			},
+			{
+				Name:        "unset",
+				Description: "Remove a setting from the config file so its default applies",
+				Args:        []argSpec{{Name: "key", Complete: completeSettings}},
+				Handler:     handlerConfigUnset,
+			},
+			{Name: "path", Description: "Print the config file in use", Handler: handlerConfigPath},
+		},
+	})
+
+	cmds.register(&commandSpec{
+		Name:        "profile",
+		Description: "List config profiles, marking the one in use",
+		Handler:     handlerProfiles,
+		Subcommands: []*commandSpec{
+			{Name: "list", Description: "List config profiles, marking the one in use", Handler: handlerProfiles},
+			{
+				Name:        "use",
+				Description: "Switch to a profile for later runs",
+				Args:        []argSpec{{Name: "name", Complete: completeProfiles}},
+				Handler:     handlerProfileUse,
+			},
+			{
+				Name:        "add",
+				Description: "Add a profile with its own database",
+				Args:        []argSpec{{Name: "name"}, {Name: "db_url"}},
+				Handler:     handlerProfileAdd,
+			},
+			{
+				Name:        "rm",
+				Description: "Remove a profile",
+				Args:        []argSpec{{Name: "name", Complete: completeProfiles}},
+				Handler:     handlerProfileRemove,
+			},
+		},
+	})
+
+	// Server.
+	cmds.register(&commandSpec{
+		Name:        "serve",
+		Description: "Serve the web interface and REST API until Ctrl-C",
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("addr", "localhost:8080", "address to listen on")
+		},
+		Handler: handlerServe,
+	})
+
+	// Schema.
+	cmds.register(&commandSpec{
+		Name:        "migrate",
+		Description: "Manage database schema migrations",
+		Subcommands: []*commandSpec{
+			{Name: "up", Description: "Apply every pending migration", Handler: handlerMigrateUp},
+			{Name: "down", Description: "Roll back the most recent migration", Handler: handlerMigrateDown},
+			{
+				Name:        "to",
+				Description: "Migrate up or down to a version",
+				Args:        []argSpec{{Name: "version"}},
+				Handler:     handlerMigrateTo,
+			},
+			{Name: "status", Description: "Show which migrations have been applied", Handler: handlerMigrateStatus},
+		},
+	})
+
+	return &cmds
+}
+
+// yesFlag defines --yes for commands that ask for confirmation.
+func yesFlag(fs *flag.FlagSet) {
+	fs.Bool("yes", false, "don't ask for confirmation")
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/praneeth-ayla/gator/internal/config"
+	"github.com/praneeth-ayla/gator/internal/output"
+)
+
+// outputFormat parses the global --output flag of a command.
+func outputFormat(cmd command) (output.Format, error) {
+	format, err := output.Parse(cmd.String("output"))
+	if err != nil {
+		return output.Format{}, fmt.Errorf("%w: %v", errUsage, err)
+	}
+	return format, nil
+}
+
+// render prints records in the format selected with --output. With the
+// default text format, text is called for each record instead so commands
+// keep their human-readable output.
+func render[T any](cmd command, records []T, text func(T)) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	if format.IsText() {
+		for _, record := range records {
+			text(record)
+		}
+		return nil
+	}
+	return output.Write(os.Stdout, format, records)
+}
+
+// useColor reports whether text output is colored: always, never, or with
+// the auto setting only when stdout is a terminal and NO_COLOR is unset.
+func useColor(s *state) bool {
+	switch s.cfg.Color {
+	case config.ColorAlways:
+		return true
+	case config.ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// highlight renders text in bold yellow.
+func highlight(text string) string {
+	return "\x1b[1;33m" + text + "\x1b[0m"
+}
+
+// The view types below are the records listing commands render. Their json
+// tags name the fields in every machine-readable format.
+
+type userView struct {
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	Current   bool      `json:"current"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type userDetailView struct {
+	Name         string    `json:"name"`
+	Role         string    `json:"role"`
+	CreatedAt    time.Time `json:"created_at"`
+	HasPassword  bool      `json:"has_password"`
+	FeedsOwned   int64     `json:"feeds_owned"`
+	Follows      int64     `json:"follows"`
+	PostsRead    int64     `json:"posts_read"`
+	PostsStarred int64     `json:"posts_starred"`
+	FilterRules  int64     `json:"filter_rules"`
+	APITokens    int64     `json:"api_tokens"`
+}
+
+type whoamiView struct {
+	Name    string `json:"name"`
+	Source  string `json:"source"`
+	Profile string `json:"profile"`
+}
+
+type tokenView struct {
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type feedView struct {
+	Name          string     `json:"name"`
+	URL           string     `json:"url"`
+	UserName      string     `json:"user_name"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+	Paused        bool       `json:"paused"`
+}
+
+type followView struct {
+	Index      int       `json:"index"`
+	FeedName   string    `json:"feed_name"`
+	FeedURL    string    `json:"feed_url"`
+	UserName   string    `json:"user_name"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type postView struct {
+	ID          string     `json:"id"`
+	Number      int64      `json:"number"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	FeedName    string     `json:"feed_name"`
+	PublishedAt time.Time  `json:"published_at"`
+	Author      string     `json:"author"`
+	Categories  []string   `json:"categories"`
+	Description string     `json:"description"`
+	ReadAt      *time.Time `json:"read_at"`
+	StarredAt   *time.Time `json:"starred_at"`
+	Highlighted bool       `json:"highlighted"`
+}
+
+type tagView struct {
+	Tag      string `json:"tag"`
+	FeedName string `json:"feed_name"`
+	FeedURL  string `json:"feed_url"`
+}
+
+type ruleView struct {
+	ID        string `json:"id"`
+	Field     string `json:"field"`
+	MatchType string `json:"match_type"`
+	Pattern   string `json:"pattern"`
+	Action    string `json:"action"`
+	Feed      string `json:"feed"`
+}
+
+type migrationView struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+type settingView struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Source      string `json:"source"`
+	Description string `json:"description"`
+}
+
+type profileView struct {
+	Name     string `json:"name"`
+	DbURL    string `json:"db_url"`
+	UserName string `json:"user_name"`
+	Active   bool   `json:"active"`
+	Saved    bool   `json:"saved"`
+}
+
+type scrapeView struct {
+	Feed     string `json:"feed"`
+	URL      string `json:"url"`
+	NewPosts int    `json:"new_posts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// nullTime converts an optional timestamp to a pointer so it renders as null
+// when unset.
+func nullTime(valid bool, t time.Time) *time.Time {
+	if !valid {
+		return nil
+	}
+	return &t
+}
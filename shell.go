@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/peterh/liner"
+)
+
+// historyFile is where the shell keeps command history, in the home directory.
+const historyFile = ".gator_history"
+
+// handlerShell runs an interactive shell that reads commands line by line and
+// runs them over the same database connection until "exit" or Ctrl-D.
+func (c *commands) handlerShell(ctx context.Context, s *state, cmd command) error {
+	// A user given with --as stays in effect for the whole session.
+	s.session = cmd.String("as")
+	defer func() { s.session = "" }()
+
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+	line.SetWordCompleter(func(input string, pos int) (string, []string, string) {
+		return c.completeLine(ctx, s, input[:pos], input[pos:])
+	})
+
+	historyPath := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		historyPath = filepath.Join(home, historyFile)
+		if f, err := os.Open(historyPath); err == nil {
+			line.ReadHistory(f)
+			f.Close()
+		}
+	}
+
+	fmt.Fprintln(c.out, `gator shell: type "help" for commands, "exit" to leave`)
+	for {
+		input, err := line.Prompt("gator> ")
+		if errors.Is(err, liner.ErrPromptAborted) {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(c.out)
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		words, err := splitWords(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "gator: %v\n", err)
+			continue
+		}
+		if len(words) == 0 {
+			continue
+		}
+		line.AppendHistory(input)
+
+		switch words[0] {
+		case "exit", "quit":
+			return saveHistory(line, historyPath)
+		case "shell":
+			fmt.Fprintln(os.Stderr, "gator: already in the shell")
+			continue
+		}
+		// A failing command is reported and the shell keeps going. Ctrl-C
+		// cancels only the command that is running.
+		if err := c.run(ctx, s, words); err != nil {
+			fmt.Fprintf(os.Stderr, "gator: %v\n", classify(err))
+		}
+	}
+
+	return saveHistory(line, historyPath)
+}
+
+// completeLine completes the word under the cursor for the shell. head is the
+// line up to the cursor and tail the rest of it.
+func (c *commands) completeLine(ctx context.Context, s *state, head, tail string) (string, []string, string) {
+	start := strings.LastIndexAny(head, " \t") + 1
+	done, err := splitWords(head[:start])
+	if err != nil {
+		return head, nil, tail
+	}
+
+	candidates := c.complete(ctx, s, done, head[start:])
+	for i, candidate := range candidates {
+		// Leave the cursor after "template=" so the template can follow.
+		if !strings.HasSuffix(candidate, "=") {
+			candidates[i] = candidate + " "
+		}
+	}
+	return head[:start], candidates, tail
+}
+
+// saveHistory writes the shell history back to disk.
+func saveHistory(line *liner.State, path string) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = line.WriteHistory(f)
+	return err
+}
+
+// splitWords splits a shell line into words. Single and double quotes group
+// words containing spaces, and a backslash escapes the next character.
+func splitWords(input string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord, escaped := false, false
+	var quote rune
+
+	for _, r := range input {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("%w: unterminated quote", errUsage)
+	}
+	if escaped {
+		return nil, fmt.Errorf("%w: line ends with a backslash", errUsage)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
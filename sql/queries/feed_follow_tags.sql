@@ -0,0 +1,32 @@
+-- name: AddFeedFollowTag :exec
+INSERT INTO feed_follow_tags (id, created_at, updated_at, feed_follow_id, name)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (feed_follow_id, name) DO NOTHING;
+
+-- name: RemoveFeedFollowTag :execrows
+DELETE FROM feed_follow_tags
+WHERE feed_follow_id = $1 AND name = $2;
+
+-- name: GetTaggedFeedFollowsForUser :many
+SELECT feed_follow_tags.name AS tag_name, feeds.name AS feed_name, feeds.url AS feed_url
+FROM feed_follows
+INNER JOIN feeds ON feeds.id = feed_follows.feed_id
+LEFT JOIN feed_follow_tags ON feed_follow_tags.feed_follow_id = feed_follows.id
+WHERE feed_follows.user_id = $1
+ORDER BY feed_follow_tags.name NULLS LAST, feeds.name;
+
+-- name: RenameTagForUser :execrows
+UPDATE feed_follow_tags
+SET name = sqlc.arg(new_name), updated_at = sqlc.arg(updated_at)
+WHERE name = sqlc.arg(old_name)
+  AND feed_follow_id IN (SELECT id FROM feed_follows WHERE user_id = sqlc.arg(user_id))
+  AND NOT EXISTS (
+    SELECT 1 FROM feed_follow_tags AS existing
+    WHERE existing.feed_follow_id = feed_follow_tags.feed_follow_id
+      AND existing.name = sqlc.arg(new_name)
+  );
+
+-- name: DeleteTagForUser :execrows
+DELETE FROM feed_follow_tags
+WHERE name = $2
+  AND feed_follow_id IN (SELECT id FROM feed_follows WHERE user_id = $1);
@@ -20,13 +20,14 @@ INNER JOIN feeds ON feeds.id = inserted_feed_follow.feed_id;
 
 
 -- name: GetFeedFollowsForUser :many
-SELECT users.name as user_name, feeds.name as feed_name, feed_follows.* FROM feed_follows 
+SELECT users.name as user_name, feeds.name as feed_name, feeds.url as feed_url, feed_follows.* FROM feed_follows 
 INNER JOIN users ON users.id = feed_follows.user_id 
 INNER JOIN feeds ON feeds.id = feed_follows.feed_id 
-WHERE feed_follows.user_id = $1;
+WHERE feed_follows.user_id = $1
+ORDER BY feed_follows.created_at, feed_follows.id;
 
 -- sql
--- name: DeleteFeedFollow :exec
+-- name: DeleteFeedFollow :execrows
 WITH feed_follow AS (
   SELECT id FROM feeds WHERE feeds.url = $1
 ),
@@ -35,4 +36,39 @@ user_follow AS (
 )
 DELETE FROM feed_follows
 WHERE feed_id = (SELECT id FROM feed_follow)
-  AND user_id = (SELECT id FROM user_follow);
\ No newline at end of file
+  AND user_id = (SELECT id FROM user_follow);
+
+-- name: GetFeedFollowForUserByUrl :one
+SELECT feed_follows.* FROM feed_follows
+INNER JOIN feeds ON feeds.id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1 AND feeds.url = $2;
+
+-- name: GetFollowerIDsForFeed :many
+SELECT user_id FROM feed_follows WHERE feed_id = $1;
+
+-- name: GetFollowedFeedsWithUnreadCounts :many
+SELECT feeds.id, feeds.name, feeds.url, COUNT(posts.id) AS unread
+FROM feed_follows
+INNER JOIN feeds ON feeds.id = feed_follows.feed_id
+LEFT JOIN posts ON posts.feed_id = feeds.id AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+      AND post_states.user_id = feed_follows.user_id
+      AND (post_states.read_at IS NOT NULL OR post_states.hidden_by_rule IS NOT NULL)
+)
+WHERE feed_follows.user_id = $1
+GROUP BY feeds.id, feeds.name, feeds.url
+ORDER BY feeds.name;
+
+-- name: GetOldestOtherFollower :one
+SELECT user_id FROM feed_follows
+WHERE feed_id = $1 AND user_id <> $2
+ORDER BY created_at, id
+LIMIT 1;
+
+-- name: GetFollowedFeedsForUser :many
+SELECT feeds.*
+FROM feed_follows
+INNER JOIN feeds ON feeds.id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1
+ORDER BY feeds.number;
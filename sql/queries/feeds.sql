@@ -1,12 +1,13 @@
 -- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, number)
 VALUES(
     $1,
     $2, 
     $3, 
     $4, 
     $5,
-    $6
+    $6,
+    (SELECT COALESCE(MAX(number), 0) + 1 FROM feeds)
 )
 RETURNING *;
 
@@ -24,5 +25,42 @@ WHERE id = $3;
 -- name: GetNextFeedToFetch :one
 SELECT *
 FROM feeds
+WHERE NOT paused
 ORDER BY last_fetched_at NULLS FIRST, id
-LIMIT 1;
\ No newline at end of file
+LIMIT 1;
+
+-- name: GetFeedById :one
+SELECT * FROM feeds WHERE id = $1;
+
+-- name: GetFeedsOwnedByUser :many
+SELECT feeds.id, feeds.name, feeds.url, (
+    SELECT COUNT(*) FROM feed_follows
+    WHERE feed_follows.feed_id = feeds.id AND feed_follows.user_id <> feeds.user_id
+) AS other_followers
+FROM feeds
+WHERE feeds.user_id = $1
+ORDER BY feeds.name;
+
+-- name: SetFeedOwner :exec
+UPDATE feeds SET user_id = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: RenameFeed :exec
+UPDATE feeds SET name = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: SetFeedUrl :exec
+UPDATE feeds SET url = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: SetFeedPaused :exec
+UPDATE feeds SET paused = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = $1;
+
+-- Holds feed numbering until the transaction ends, so feeds added at the
+-- same time get numbers one after another.
+-- name: LockFeedNumbers :exec
+SELECT pg_advisory_xact_lock(hashtext('gator.feeds.number'));
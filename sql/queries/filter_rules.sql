@@ -0,0 +1,13 @@
+-- name: CreateFilterRule :one
+INSERT INTO filter_rules (id, created_at, updated_at, user_id, feed_id, field, match_type, pattern, action)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetFilterRulesForUser :many
+SELECT * FROM filter_rules
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: DeleteFilterRuleForUser :execrows
+DELETE FROM filter_rules
+WHERE id = $1 AND user_id = $2;
@@ -0,0 +1,50 @@
+-- name: ApplyPostState :exec
+INSERT INTO post_states (id, created_at, updated_at, user_id, post_id, read_at, starred_at, read_by_rule, starred_by_rule, hidden_by_rule, highlighted_by_rule)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (user_id, post_id) DO UPDATE SET
+    updated_at = EXCLUDED.updated_at,
+    read_at = COALESCE(post_states.read_at, EXCLUDED.read_at),
+    read_by_rule = CASE WHEN post_states.read_at IS NULL THEN EXCLUDED.read_by_rule ELSE post_states.read_by_rule END,
+    starred_at = COALESCE(post_states.starred_at, EXCLUDED.starred_at),
+    starred_by_rule = CASE WHEN post_states.starred_at IS NULL THEN EXCLUDED.starred_by_rule ELSE post_states.starred_by_rule END,
+    hidden_by_rule = COALESCE(post_states.hidden_by_rule, EXCLUDED.hidden_by_rule),
+    highlighted_by_rule = COALESCE(post_states.highlighted_by_rule, EXCLUDED.highlighted_by_rule);
+
+-- name: SetPostRead :exec
+INSERT INTO post_states (id, created_at, updated_at, user_id, post_id, read_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, post_id) DO UPDATE SET
+    updated_at = EXCLUDED.updated_at,
+    read_at = EXCLUDED.read_at,
+    read_by_rule = NULL;
+
+-- name: SetPostStarred :exec
+INSERT INTO post_states (id, created_at, updated_at, user_id, post_id, starred_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, post_id) DO UPDATE SET
+    updated_at = EXCLUDED.updated_at,
+    starred_at = EXCLUDED.starred_at,
+    starred_by_rule = NULL;
+
+-- name: GetPostStatesSetByRule :many
+SELECT post_states.*, posts.feed_id, posts.title, posts.description, posts.author, posts.categories
+FROM post_states
+INNER JOIN posts ON posts.id = post_states.post_id
+WHERE post_states.read_by_rule = sqlc.arg(rule_id)
+   OR post_states.starred_by_rule = sqlc.arg(rule_id)
+   OR post_states.hidden_by_rule = sqlc.arg(rule_id)
+   OR post_states.highlighted_by_rule = sqlc.arg(rule_id);
+
+-- name: RevertRuleStates :exec
+UPDATE post_states SET
+    updated_at = sqlc.arg(updated_at),
+    read_at = CASE WHEN read_by_rule = sqlc.arg(rule_id) THEN NULL ELSE read_at END,
+    read_by_rule = CASE WHEN read_by_rule = sqlc.arg(rule_id) THEN NULL ELSE read_by_rule END,
+    starred_at = CASE WHEN starred_by_rule = sqlc.arg(rule_id) THEN NULL ELSE starred_at END,
+    starred_by_rule = CASE WHEN starred_by_rule = sqlc.arg(rule_id) THEN NULL ELSE starred_by_rule END,
+    hidden_by_rule = CASE WHEN hidden_by_rule = sqlc.arg(rule_id) THEN NULL ELSE hidden_by_rule END,
+    highlighted_by_rule = CASE WHEN highlighted_by_rule = sqlc.arg(rule_id) THEN NULL ELSE highlighted_by_rule END
+WHERE read_by_rule = sqlc.arg(rule_id)
+   OR starred_by_rule = sqlc.arg(rule_id)
+   OR hidden_by_rule = sqlc.arg(rule_id)
+   OR highlighted_by_rule = sqlc.arg(rule_id);
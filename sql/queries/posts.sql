@@ -0,0 +1,146 @@
+-- name: CreatePost :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, author, categories, number)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    (SELECT COALESCE(MAX(number), 0) + 1 FROM posts)
+)
+ON CONFLICT (url) DO NOTHING
+RETURNING *;
+
+-- name: GetPostsForUser :many
+SELECT posts.*, feeds.name AS feed_name,
+    post_states.read_at, post_states.starred_at, post_states.highlighted_by_rule IS NOT NULL AS highlighted
+FROM posts
+INNER JOIN feeds ON feeds.id = posts.feed_id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+  AND post_states.hidden_by_rule IS NULL
+ORDER BY posts.published_at DESC NULLS LAST, posts.created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: GetPostsForUserByTag :many
+SELECT posts.*, feeds.name AS feed_name,
+    post_states.read_at, post_states.starred_at, post_states.highlighted_by_rule IS NOT NULL AS highlighted
+FROM posts
+INNER JOIN feeds ON feeds.id = posts.feed_id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+INNER JOIN feed_follow_tags ON feed_follow_tags.feed_follow_id = feed_follows.id
+WHERE feed_follows.user_id = $1 AND feed_follow_tags.name = $2
+  AND post_states.hidden_by_rule IS NULL
+ORDER BY posts.published_at DESC NULLS LAST, posts.created_at DESC
+LIMIT $3 OFFSET $4;
+
+-- name: GetPostForUserByNumber :one
+SELECT posts.*
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1 AND posts.number = $2;
+
+-- name: GetPostsForUserByFeed :many
+SELECT posts.*, feeds.name AS feed_name,
+    post_states.read_at, post_states.starred_at, post_states.highlighted_by_rule IS NOT NULL AS highlighted
+FROM posts
+INNER JOIN feeds ON feeds.id = posts.feed_id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND posts.feed_id = $2
+  AND post_states.hidden_by_rule IS NULL
+ORDER BY posts.published_at DESC NULLS LAST, posts.created_at DESC
+LIMIT $3 OFFSET $4;
+
+-- name: GetPostWithStateForUser :one
+SELECT posts.*, feeds.name AS feed_name,
+    post_states.read_at, post_states.starred_at, post_states.highlighted_by_rule IS NOT NULL AS highlighted
+FROM posts
+INNER JOIN feeds ON feeds.id = posts.feed_id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND posts.number = $2
+  AND post_states.hidden_by_rule IS NULL;
+
+-- name: GetPostsForUserAfterNumber :many
+SELECT posts.*, feeds.name AS feed_name,
+    post_states.read_at, post_states.starred_at, post_states.highlighted_by_rule IS NOT NULL AS highlighted
+FROM posts
+INNER JOIN feeds ON feeds.id = posts.feed_id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND posts.number > $2
+  AND post_states.hidden_by_rule IS NULL
+ORDER BY posts.number
+LIMIT $3;
+
+-- name: GetPostsForUserBeforeNumber :many
+SELECT posts.*, feeds.name AS feed_name,
+    post_states.read_at, post_states.starred_at, post_states.highlighted_by_rule IS NOT NULL AS highlighted
+FROM posts
+INNER JOIN feeds ON feeds.id = posts.feed_id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND posts.number < $2
+  AND post_states.hidden_by_rule IS NULL
+ORDER BY posts.number DESC
+LIMIT $3;
+
+-- name: CountPostsForUser :one
+SELECT COUNT(*)
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+  AND post_states.hidden_by_rule IS NULL;
+
+-- name: GetUnreadPostNumbersForUser :many
+SELECT posts.number
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+  AND post_states.read_at IS NULL
+  AND post_states.hidden_by_rule IS NULL
+ORDER BY posts.number;
+
+-- name: GetStarredPostNumbersForUser :many
+SELECT posts.number
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+  AND post_states.starred_at IS NOT NULL
+  AND post_states.hidden_by_rule IS NULL
+ORDER BY posts.number;
+
+-- name: GetUnreadPostsForUserBefore :many
+SELECT posts.id, posts.feed_id
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND posts.created_at < $2
+  AND post_states.read_at IS NULL
+  AND post_states.hidden_by_rule IS NULL;
+
+-- name: GetFollowedPostsForUser :many
+SELECT posts.*
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1;
+
+-- name: GetPostsForFeed :many
+SELECT * FROM posts
+WHERE feed_id = $1;
+
+-- Holds post numbering until the transaction ends, so concurrent scrapes
+-- number their posts one after another, in the order they commit.
+-- name: LockPostNumbers :exec
+SELECT pg_advisory_xact_lock(hashtext('gator.posts.number'));
@@ -0,0 +1,15 @@
+-- name: CreateSession :exec
+INSERT INTO sessions (id, created_at, expires_at, user_id, token_hash)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetSessionUser :one
+SELECT users.id, users.name, users.created_at, users.updated_at, users.password_hash, users.role
+FROM sessions
+INNER JOIN users ON users.id = sessions.user_id
+WHERE sessions.token_hash = $1 AND sessions.expires_at > $2;
+
+-- name: DeleteSession :exec
+DELETE FROM sessions WHERE token_hash = $1;
+
+-- name: DeleteExpiredSessions :exec
+DELETE FROM sessions WHERE expires_at <= $1;
@@ -1,10 +1,12 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+INSERT INTO users (id, created_at, updated_at, name, password_hash, role)
 VALUES (
     $1,
     $2,
     $3,
-    $4
+    $4,
+    $5,
+    $6
 )
 RETURNING *;
 
@@ -19,4 +21,31 @@ DELETE FROM users;
 SELECT * FROM users;
 
 -- name: GetUserById :one
-SELECT * FROM users WHERE id = $1;
\ No newline at end of file
+SELECT * FROM users WHERE id = $1;
+
+-- name: SetUserPassword :exec
+UPDATE users SET password_hash = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: SetUserRole :exec
+UPDATE users SET role = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: CountAdmins :one
+SELECT COUNT(*) FROM users WHERE role = 'admin';
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1;
+
+-- name: RenameUser :exec
+UPDATE users SET name = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: GetUserStats :one
+SELECT
+    (SELECT COUNT(*) FROM feeds WHERE feeds.user_id = $1) AS feeds_owned,
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.user_id = $1) AS follows,
+    (SELECT COUNT(*) FROM post_states WHERE post_states.user_id = $1 AND post_states.read_at IS NOT NULL) AS posts_read,
+    (SELECT COUNT(*) FROM post_states WHERE post_states.user_id = $1 AND post_states.starred_at IS NOT NULL) AS posts_starred,
+    (SELECT COUNT(*) FROM filter_rules WHERE filter_rules.user_id = $1) AS filter_rules,
+    (SELECT COUNT(*) FROM api_tokens WHERE api_tokens.user_id = $1) AS api_tokens;
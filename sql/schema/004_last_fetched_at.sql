@@ -2,4 +2,4 @@
 ALTER TABLE feeds ADD COLUMN last_fetched_at TIMESTAMP;
 
 -- +goose Down
-ALTER TABLE feeds DROP COLUMN last_fetched_at TIMESTAMP;
\ No newline at end of file
+ALTER TABLE feeds DROP COLUMN last_fetched_at;
\ No newline at end of file
@@ -0,0 +1,42 @@
+-- +goose Up
+-- Rule actions are recorded with the rule that took them, so removing the
+-- rule can take them back. Earlier hides and highlights are credited to the
+-- user's oldest rule taking that action on the post's feed, and dropped if
+-- that rule is gone.
+ALTER TABLE post_states ADD COLUMN read_by_rule UUID;
+ALTER TABLE post_states ADD COLUMN starred_by_rule UUID;
+ALTER TABLE post_states ADD COLUMN hidden_by_rule UUID;
+ALTER TABLE post_states ADD COLUMN highlighted_by_rule UUID;
+UPDATE post_states SET hidden_by_rule = (
+    SELECT filter_rules.id FROM filter_rules
+    INNER JOIN posts ON posts.id = post_states.post_id
+    WHERE filter_rules.user_id = post_states.user_id
+      AND filter_rules.action = 'hide'
+      AND (filter_rules.feed_id IS NULL OR filter_rules.feed_id = posts.feed_id)
+    ORDER BY filter_rules.created_at
+    LIMIT 1
+)
+WHERE hidden;
+UPDATE post_states SET highlighted_by_rule = (
+    SELECT filter_rules.id FROM filter_rules
+    INNER JOIN posts ON posts.id = post_states.post_id
+    WHERE filter_rules.user_id = post_states.user_id
+      AND filter_rules.action = 'highlight'
+      AND (filter_rules.feed_id IS NULL OR filter_rules.feed_id = posts.feed_id)
+    ORDER BY filter_rules.created_at
+    LIMIT 1
+)
+WHERE highlighted;
+ALTER TABLE post_states DROP COLUMN hidden;
+ALTER TABLE post_states DROP COLUMN highlighted;
+
+-- +goose Down
+ALTER TABLE post_states ADD COLUMN hidden BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE post_states ADD COLUMN highlighted BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE post_states SET
+    hidden = hidden_by_rule IS NOT NULL,
+    highlighted = highlighted_by_rule IS NOT NULL;
+ALTER TABLE post_states DROP COLUMN highlighted_by_rule;
+ALTER TABLE post_states DROP COLUMN hidden_by_rule;
+ALTER TABLE post_states DROP COLUMN starred_by_rule;
+ALTER TABLE post_states DROP COLUMN read_by_rule;
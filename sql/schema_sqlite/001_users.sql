@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE
+    users (
+        id TEXT PRIMARY KEY,
+        name TEXT UNIQUE NOT NULL,
+        created_at TIMESTAMP NOT NULL,
+        updated_at TIMESTAMP NOT NULL
+    );
+
+-- +goose Down 
+DROP TABLE users;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE feed_follow_tags (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    feed_follow_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    UNIQUE (feed_follow_id, name),
+    FOREIGN KEY (feed_follow_id) REFERENCES feed_follows(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE feed_follow_tags;
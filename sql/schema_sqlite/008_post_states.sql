@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE post_states (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id TEXT NOT NULL,
+    post_id TEXT NOT NULL,
+    read_at TIMESTAMP,
+    starred_at TIMESTAMP,
+    hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    highlighted BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (user_id, post_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE post_states;
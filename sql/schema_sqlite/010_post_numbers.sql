@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN number BIGINT NOT NULL DEFAULT 0;
+UPDATE posts SET number = numbered.n
+FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS n FROM posts) AS numbered
+WHERE posts.id = numbered.id;
+CREATE UNIQUE INDEX posts_number_idx ON posts (number);
+
+-- +goose Down
+DROP INDEX posts_number_idx;
+ALTER TABLE posts DROP COLUMN number;
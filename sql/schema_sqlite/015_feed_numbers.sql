@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN number BIGINT NOT NULL DEFAULT 0;
+UPDATE feeds SET number = numbered.n
+FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS n FROM feeds) AS numbered
+WHERE feeds.id = numbered.id;
+CREATE UNIQUE INDEX feeds_number_idx ON feeds (number);
+
+-- +goose Down
+DROP INDEX feeds_number_idx;
+ALTER TABLE feeds DROP COLUMN number;
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/praneeth-ayla/gator/internal/database"
+	"github.com/rivo/tview"
+)
+
+// tuiCountInterval is how often the TUI reloads unread counts, so posts
+// saved by an agg running elsewhere show up.
+const tuiCountInterval = 30 * time.Second
+
+// tuiHelp is shown in the status line.
+const tuiHelp = "tab switch pane  j/k move  enter open  r read  s star  o browser  R refresh  q quit"
+
+// openURL opens a link in the user's browser.
+var openURL = func(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
+
+// handlerTUI runs the full-screen reader until the user quits.
+func handlerTUI(ctx context.Context, s *state, cmd command, user database.User) error {
+	ui, err := newTUI(ctx, &reader{s: s, user: user})
+	if err != nil {
+		return err
+	}
+
+	// Log lines from scraping would draw over the screen.
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	stop := ui.watchCounts(tuiCountInterval)
+	defer stop()
+	return ui.app.Run()
+}
+
+// tui is the three-pane reader: sources, the posts of the selected source,
+// and the selected post.
+type tui struct {
+	// ctx bounds the reader's queries and fetches for as long as it runs.
+	ctx     context.Context
+	r       *reader
+	app     *tview.Application
+	sources *tview.List
+	posts   *tview.List
+	content *tview.TextView
+	status  *tview.TextView
+	panes   []tview.Primitive
+
+	sourceItems []source
+	postItems   []readerPost
+	// rebuilding silences list change callbacks while items are replaced.
+	rebuilding bool
+}
+
+// newTUI lays out the reader and loads its first source.
+func newTUI(ctx context.Context, r *reader) (*tui, error) {
+	ui := &tui{
+		ctx:     ctx,
+		r:       r,
+		app:     tview.NewApplication(),
+		sources: tview.NewList(),
+		posts:   tview.NewList(),
+		content: tview.NewTextView(),
+		status:  tview.NewTextView(),
+	}
+	ui.panes = []tview.Primitive{ui.sources, ui.posts, ui.content}
+
+	ui.sources.ShowSecondaryText(false).SetBorder(true).SetTitle(" Follows ")
+	ui.posts.ShowSecondaryText(false).SetBorder(true).SetTitle(" Posts ")
+	ui.content.SetWordWrap(true).SetBorder(true).SetTitle(" Post ")
+	ui.status.SetText(tuiHelp)
+
+	ui.sources.SetChangedFunc(func(i int, _, _ string, _ rune) {
+		if !ui.rebuilding {
+			ui.showSource(i)
+		}
+	})
+	ui.sources.SetSelectedFunc(func(int, string, string, rune) {
+		ui.app.SetFocus(ui.posts)
+	})
+	ui.posts.SetChangedFunc(func(i int, _, _ string, _ rune) {
+		if !ui.rebuilding {
+			ui.showPost(i)
+		}
+	})
+	ui.posts.SetSelectedFunc(func(i int, _, _ string, _ rune) {
+		ui.openPost(i)
+	})
+
+	columns := tview.NewFlex().
+		AddItem(ui.sources, 30, 0, true).
+		AddItem(ui.posts, 0, 2, false).
+		AddItem(ui.content, 0, 3, false)
+	root := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(columns, 0, 1, true).
+		AddItem(ui.status, 1, 0, false)
+	ui.app.SetRoot(root, true).SetInputCapture(ui.handleKey)
+
+	sources, err := r.loadSources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ui.setSources(sources)
+	ui.showSource(0)
+	return ui, nil
+}
+
+// handleKey implements the reader's keyboard shortcuts. Keys it doesn't use
+// go on to the focused pane.
+func (ui *tui) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyTab:
+		ui.cycleFocus(1)
+		return nil
+	case tcell.KeyBacktab:
+		ui.cycleFocus(-1)
+		return nil
+	case tcell.KeyRune:
+	default:
+		return event
+	}
+
+	switch event.Rune() {
+	case 'q':
+		ui.app.Stop()
+	case 'j':
+		return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+	case 'k':
+		return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+	case 'r':
+		ui.toggleRead()
+	case 's':
+		ui.toggleStar()
+	case 'o':
+		ui.openInBrowser()
+	case 'R':
+		ui.refresh()
+	default:
+		return event
+	}
+	return nil
+}
+
+// cycleFocus moves the focus to the next or previous pane.
+func (ui *tui) cycleFocus(step int) {
+	current := 0
+	for i, pane := range ui.panes {
+		if pane.HasFocus() {
+			current = i
+		}
+	}
+	next := (current + step + len(ui.panes)) % len(ui.panes)
+	ui.app.SetFocus(ui.panes[next])
+}
+
+// setSources replaces the sources pane, keeping the selected source when it
+// still exists. It reports whether the selection moved to another source.
+func (ui *tui) setSources(sources []source) bool {
+	selected := ""
+	if current := ui.sources.GetCurrentItem(); current < len(ui.sourceItems) {
+		selected = ui.sourceItems[current].key()
+	}
+
+	ui.rebuilding = true
+	defer func() { ui.rebuilding = false }()
+
+	ui.sourceItems = sources
+	ui.sources.Clear()
+	index := 0
+	for i, src := range sources {
+		ui.sources.AddItem(tview.Escape(src.label()), "", 0, nil)
+		if src.key() == selected {
+			index = i
+		}
+	}
+	ui.sources.SetCurrentItem(index)
+	return selected != "" && sources[index].key() != selected
+}
+
+// updateCounts reloads unread counts, and the post list too if the selected
+// source went away.
+func (ui *tui) updateCounts() {
+	sources, err := ui.r.loadSources(ui.ctx)
+	if err != nil {
+		ui.setStatus("couldn't load follows: %v", err)
+		return
+	}
+	if ui.setSources(sources) {
+		ui.showSource(ui.sources.GetCurrentItem())
+	}
+}
+
+// watchCounts reloads unread counts every interval while the TUI runs. The
+// returned function stops it.
+func (ui *tui) watchCounts(interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				ui.app.QueueUpdateDraw(ui.updateCounts)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+// showSource lists the posts of a source and previews the first one.
+func (ui *tui) showSource(i int) {
+	if i < 0 || i >= len(ui.sourceItems) {
+		return
+	}
+	posts, err := ui.r.loadPosts(ui.ctx, ui.sourceItems[i])
+	if err != nil {
+		ui.setStatus("couldn't load posts: %v", err)
+		return
+	}
+
+	ui.rebuilding = true
+	ui.postItems = posts
+	ui.posts.Clear()
+	for _, post := range posts {
+		ui.posts.AddItem(postLabel(post.view), "", 0, nil)
+	}
+	ui.posts.SetCurrentItem(0)
+	ui.rebuilding = false
+
+	ui.showPost(0)
+}
+
+// showPost previews a post in the content pane.
+func (ui *tui) showPost(i int) {
+	if i < 0 || i >= len(ui.postItems) {
+		ui.content.SetText("")
+		return
+	}
+	ui.content.SetText(postContent(ui.postItems[i].view)).ScrollToBeginning()
+}
+
+// openPost shows a post, marks it read and moves the focus to it.
+func (ui *tui) openPost(i int) {
+	if i < 0 || i >= len(ui.postItems) {
+		return
+	}
+	ui.showPost(i)
+	if ui.postItems[i].view.ReadAt == nil {
+		ui.setRead(i, true)
+	}
+	ui.app.SetFocus(ui.content)
+}
+
+// selectedPost returns the index of the selected post, or -1 without one.
+func (ui *tui) selectedPost() int {
+	i := ui.posts.GetCurrentItem()
+	if i >= len(ui.postItems) {
+		return -1
+	}
+	return i
+}
+
+func (ui *tui) toggleRead() {
+	if i := ui.selectedPost(); i >= 0 {
+		ui.setRead(i, ui.postItems[i].view.ReadAt == nil)
+	}
+}
+
+func (ui *tui) toggleStar() {
+	i := ui.selectedPost()
+	if i < 0 {
+		return
+	}
+	post := &ui.postItems[i]
+	if err := ui.r.setStarred(ui.ctx, post, post.view.StarredAt == nil); err != nil {
+		ui.setStatus("couldn't star post: %v", err)
+		return
+	}
+	ui.posts.SetItemText(i, postLabel(post.view), "")
+}
+
+// setRead marks a post read or unread and updates the unread counts.
+func (ui *tui) setRead(i int, read bool) {
+	post := &ui.postItems[i]
+	if err := ui.r.setRead(ui.ctx, post, read); err != nil {
+		ui.setStatus("couldn't mark post: %v", err)
+		return
+	}
+	ui.posts.SetItemText(i, postLabel(post.view), "")
+	ui.updateCounts()
+}
+
+func (ui *tui) openInBrowser() {
+	i := ui.selectedPost()
+	if i < 0 {
+		return
+	}
+	if err := openURL(ui.postItems[i].view.URL); err != nil {
+		ui.setStatus("couldn't open browser: %v", err)
+	}
+}
+
+// refresh scrapes the selected source's feeds in the background, then
+// reloads its posts.
+func (ui *tui) refresh() {
+	i := ui.sources.GetCurrentItem()
+	if i >= len(ui.sourceItems) {
+		return
+	}
+	src := ui.sourceItems[i]
+	ui.setStatus("refreshing %s...", strings.TrimSpace(src.label()))
+
+	go func() {
+		found, err := ui.r.refresh(ui.ctx, src)
+		ui.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ui.setStatus("refresh failed: %v", err)
+			} else {
+				ui.setStatus("refreshed, %d new posts", found)
+			}
+			ui.updateCounts()
+			ui.showSource(ui.sources.GetCurrentItem())
+		})
+	}()
+}
+
+func (ui *tui) setStatus(format string, args ...any) {
+	ui.status.SetText(fmt.Sprintf(format, args...) + "  |  " + tuiHelp)
+}
+
+// postLabel is a post's line in the post list: a dot while unread, a star
+// when starred, then its number and title.
+func postLabel(post postView) string {
+	unread, star := " ", " "
+	if post.ReadAt == nil {
+		unread = "●"
+	}
+	if post.StarredAt != nil {
+		star = "★"
+	}
+	return tview.Escape(fmt.Sprintf("%s%s [%d] %s", unread, star, post.Number, post.Title))
+}
+
+// postContent is the text shown for a post in the content pane.
+func postContent(post postView) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", post.Title)
+	fmt.Fprintf(&b, "%s | %s", post.FeedName, post.PublishedAt.Format("Mon Jan 2 2006 15:04"))
+	if post.Author != "" {
+		fmt.Fprintf(&b, " | %s", post.Author)
+	}
+	fmt.Fprintf(&b, "\n%s\n\n%s\n", post.URL, plainText(post.Description))
+	return b.String()
+}
+
+var (
+	htmlBreaks     = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/h[1-6])\s*/?>`)
+	htmlTags       = regexp.MustCompile(`<[^>]*>`)
+	repeatedBlanks = regexp.MustCompile(`\n{3,}`)
+)
+
+// plainText turns a feed's HTML description into readable plain text.
+func plainText(description string) string {
+	text := htmlBreaks.ReplaceAllString(description, "\n")
+	text = htmlTags.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	text = repeatedBlanks.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/praneeth-ayla/gator/internal/auth"
+	"github.com/praneeth-ayla/gator/internal/database"
+)
+
+// webFS embeds the web interface's templates and static files, so serve
+// needs nothing next to the binary.
+//
+//go:embed web/templates/*.html web/static/*
+var webFS embed.FS
+
+// sessionCookie holds the token of a logged-in browser's session.
+const sessionCookie = "gator_session"
+
+// sessionLifetime is how long a web login lasts.
+const sessionLifetime = 30 * 24 * time.Hour
+
+// webPages holds each page's template, parsed together with the layout.
+var webPages = parseWebPages("login", "posts", "post", "follows", "error")
+
+// parseWebPages parses web/templates/<name>.html with the layout for every
+// page name.
+func parseWebPages(names ...string) map[string]*template.Template {
+	funcs := template.FuncMap{
+		"date": func(t time.Time) string { return t.Format("Mon Jan 2, 2006") },
+	}
+	pages := make(map[string]*template.Template, len(names))
+	for _, name := range names {
+		pages[name] = template.Must(template.New("layout.html").Funcs(funcs).ParseFS(webFS,
+			"web/templates/layout.html", "web/templates/"+name+".html"))
+	}
+	return pages
+}
+
+// web serves the browser interface: pages rendered on the server with plain
+// HTML forms, so it needs no JavaScript. Users log in with their name and
+// password and stay logged in with a session cookie.
+type web struct {
+	s *state
+}
+
+// webHandler serves a page for a logged-in user. A returned error is shown
+// on an error page.
+type webHandler func(w http.ResponseWriter, r *http.Request, user database.User) error
+
+// pageData is what every page template gets: the page's title, the
+// logged-in user's name, an error to show, and the page's own data.
+type pageData struct {
+	Title string
+	User  string
+	Error string
+	Data  any
+}
+
+// newWeb routes the web interface. Forms only work from its own pages:
+// cross-origin POSTs are refused.
+func newWeb(s *state) http.Handler {
+	wb := &web{s: s}
+	static, err := fs.Sub(webFS, "web/static")
+	if err != nil {
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServerFS(static)))
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/posts", http.StatusSeeOther)
+	})
+	mux.HandleFunc("GET /login", wb.handleLoginPage)
+	mux.HandleFunc("POST /login", wb.handleLogin)
+	mux.HandleFunc("POST /logout", wb.handleLogout)
+	mux.Handle("GET /posts", wb.authed(wb.handlePosts))
+	mux.Handle("GET /posts/{number}", wb.authed(wb.handlePost))
+	mux.Handle("POST /posts/{number}/read", wb.authed(wb.handleSetRead))
+	mux.Handle("POST /posts/{number}/star", wb.authed(wb.handleSetStarred))
+	mux.Handle("GET /follows", wb.authed(wb.handleFollows))
+	mux.Handle("POST /follows", wb.authed(wb.handleFollow))
+	mux.Handle("POST /unfollow", wb.authed(wb.handleUnfollow))
+	return http.NewCrossOriginProtection().Handler(mux)
+}
+
+// authed runs handler for the user whose session the request carries, or
+// sends the browser to log in first.
+func (wb *web) authed(handler webHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := wb.sessionUser(r)
+		if errors.Is(err, errNotLoggedIn) {
+			http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+			return
+		}
+		if err == nil {
+			err = handler(w, r, user)
+		}
+		if err != nil {
+			status, _, message := httpError(err)
+			wb.render(w, status, "error", pageData{Title: http.StatusText(status), User: user.Name, Error: message})
+		}
+	})
+}
+
+// sessionUser returns the user logged in with the request's session cookie.
+func (wb *web) sessionUser(r *http.Request) (database.User, error) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return database.User{}, newError(errNotLoggedIn, "not logged in")
+	}
+	user, err := wb.s.db.GetSessionUser(r.Context(), database.GetSessionUserParams{
+		TokenHash: auth.HashToken(cookie.Value),
+		ExpiresAt: time.Now(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, newError(errNotLoggedIn, "your session has expired")
+	}
+	return user, err
+}
+
+// render writes a page. Template errors can only be logged, since the
+// status has been sent by then.
+func (wb *web) render(w http.ResponseWriter, status int, page string, data pageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := webPages[page].Execute(w, data); err != nil {
+		log.Printf("serve: %v", err)
+	}
+}
+
+// handleLoginPage shows the login form.
+func (wb *web) handleLoginPage(w http.ResponseWriter, r *http.Request) {
+	wb.render(w, http.StatusOK, "login", pageData{Title: "Log in", Data: localPath(r.URL.Query().Get("next"))})
+}
+
+// handleLogin checks a name and password, then starts a session. Only users
+// with a password can log in on the web, whatever the auth setting says.
+func (wb *web) handleLogin(w http.ResponseWriter, r *http.Request) {
+	next := localPath(r.FormValue("next"))
+	user, err := wb.login(r, r.FormValue("name"), r.FormValue("password"))
+	if err != nil {
+		status, _, message := httpError(err)
+		wb.render(w, status, "login", pageData{Title: "Log in", Error: message, Data: next})
+		return
+	}
+
+	token, err := newSession(r.Context(), wb.s, user.ID, sessionLifetime)
+	if err != nil {
+		status, _, message := httpError(err)
+		wb.render(w, status, "login", pageData{Title: "Log in", Error: message, Data: next})
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(sessionLifetime.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// login returns the user a name and password belong to. Unknown names,
+// users without a password and wrong passwords all get the same answer, so
+// the page doesn't tell who has an account.
+func (wb *web) login(r *http.Request, name, password string) (database.User, error) {
+	wrong := newError(errAuth, "wrong name or password")
+	user, err := wb.s.db.GetUser(r.Context(), name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, wrong
+	}
+	if err != nil {
+		return user, err
+	}
+	if !user.PasswordHash.Valid {
+		return user, wrong
+	}
+	err = auth.CheckPassword(user.PasswordHash.String, password)
+	if errors.Is(err, auth.ErrMismatch) {
+		return user, wrong
+	}
+	return user, err
+}
+
+// handleLogout ends the session and forgets the cookie.
+func (wb *web) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		if err := wb.s.db.DeleteSession(r.Context(), auth.HashToken(cookie.Value)); err != nil {
+			log.Printf("serve: %v", err)
+		}
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// webSource is a follow or tag in the posts page's sidebar.
+type webSource struct {
+	Label    string
+	Link     string
+	Unread   int64
+	Selected bool
+}
+
+// postsPage is the data of the posts page.
+type postsPage struct {
+	Sources []webSource
+	Posts   []postView
+	// Back is the page's own address, which post forms return to.
+	Back string
+}
+
+// handlePosts lists the newest posts of every follow, or of one tag with
+// the tag parameter or one feed with the feed parameter, like the TUI.
+func (wb *web) handlePosts(w http.ResponseWriter, r *http.Request, user database.User) error {
+	rd := &reader{s: wb.s, user: user}
+	sources, err := rd.loadSources(r.Context())
+	if err != nil {
+		return err
+	}
+
+	tag, feed := r.URL.Query().Get("tag"), r.URL.Query().Get("feed")
+	selected := sources[0]
+	page := postsPage{Back: r.URL.RequestURI()}
+	found := tag == "" && feed == ""
+	for _, src := range sources {
+		ws := webSource{Label: src.name, Link: "/posts", Unread: src.unread}
+		switch src.kind {
+		case sourceAll:
+			ws.Label = "All posts"
+			ws.Selected = tag == "" && feed == ""
+		case sourceTag:
+			ws.Label = "#" + src.name
+			ws.Link = "/posts?tag=" + url.QueryEscape(src.name)
+			ws.Selected = tag == src.name
+		case sourceFeed:
+			ws.Link = "/posts?feed=" + src.feedIDs[0].String()
+			ws.Selected = feed == src.feedIDs[0].String()
+		}
+		if ws.Selected {
+			selected, found = src, true
+		}
+		page.Sources = append(page.Sources, ws)
+	}
+	if !found {
+		return newError(errNotFound, "you don't follow that feed or tag")
+	}
+
+	posts, err := rd.loadPosts(r.Context(), selected)
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		page.Posts = append(page.Posts, post.view)
+	}
+	wb.render(w, http.StatusOK, "posts", pageData{Title: "Posts", User: user.Name, Data: page})
+	return nil
+}
+
+// handlePost shows a post and marks it read, as opening it in the TUI does.
+func (wb *web) handlePost(w http.ResponseWriter, r *http.Request, user database.User) error {
+	number, err := postNumber(r)
+	if err != nil {
+		return err
+	}
+	view, err := postByNumber(r.Context(), wb.s, user, number)
+	if err != nil {
+		return err
+	}
+	if view.ReadAt == nil {
+		id, err := uuid.Parse(view.ID)
+		if err != nil {
+			return err
+		}
+		readAt, err := setPostRead(r.Context(), wb.s, user.ID, id, true)
+		if err != nil {
+			return err
+		}
+		view.ReadAt = nullTime(readAt.Valid, readAt.Time)
+	}
+	wb.render(w, http.StatusOK, "post", pageData{Title: view.Title, User: user.Name, Data: view})
+	return nil
+}
+
+// handleSetRead marks a post read or unread, as the form's read field says.
+func (wb *web) handleSetRead(w http.ResponseWriter, r *http.Request, user database.User) error {
+	return wb.updatePost(w, r, user, func(postID uuid.UUID) error {
+		_, err := setPostRead(r.Context(), wb.s, user.ID, postID, r.FormValue("read") == "true")
+		return err
+	})
+}
+
+// handleSetStarred stars or unstars a post, as the form's starred field
+// says.
+func (wb *web) handleSetStarred(w http.ResponseWriter, r *http.Request, user database.User) error {
+	return wb.updatePost(w, r, user, func(postID uuid.UUID) error {
+		_, err := setPostStarred(r.Context(), wb.s, user.ID, postID, r.FormValue("starred") == "true")
+		return err
+	})
+}
+
+// updatePost applies update to the post a form names, then returns to the
+// page the form was on. Posts the user's rules hide aren't found.
+func (wb *web) updatePost(w http.ResponseWriter, r *http.Request, user database.User, update func(uuid.UUID) error) error {
+	number, err := postNumber(r)
+	if err != nil {
+		return err
+	}
+	view, err := postByNumber(r.Context(), wb.s, user, number)
+	if err != nil {
+		return err
+	}
+	id, err := uuid.Parse(view.ID)
+	if err != nil {
+		return err
+	}
+	if err := update(id); err != nil {
+		return err
+	}
+	back := r.FormValue("back")
+	if back == "" {
+		back = "/posts/" + strconv.FormatInt(number, 10)
+	}
+	http.Redirect(w, r, localPath(back), http.StatusSeeOther)
+	return nil
+}
+
+// followsPage is the data of the follows page.
+type followsPage struct {
+	Follows []followView
+	// Added names the feed just followed.
+	Added string
+	// URL and Name refill the follow form after an error.
+	URL  string
+	Name string
+}
+
+// handleFollows lists the user's follows with a form to follow more.
+func (wb *web) handleFollows(w http.ResponseWriter, r *http.Request, user database.User) error {
+	return wb.renderFollows(w, r, user, http.StatusOK, "", followsPage{Added: r.URL.Query().Get("added")})
+}
+
+// handleFollow follows a feed by URL, adding it when nobody has yet. Errors
+// are shown on the follows page with the form filled in again.
+func (wb *web) handleFollow(w http.ResponseWriter, r *http.Request, user database.User) error {
+	feedURL := strings.TrimSpace(r.FormValue("url"))
+	name := strings.TrimSpace(r.FormValue("name"))
+	follow, _, err := followFeed(r.Context(), wb.s, user, feedURL, name)
+	if err != nil {
+		status, _, message := httpError(err)
+		return wb.renderFollows(w, r, user, status, message, followsPage{URL: feedURL, Name: name})
+	}
+	http.Redirect(w, r, "/follows?added="+url.QueryEscape(follow.FeedName), http.StatusSeeOther)
+	return nil
+}
+
+// handleUnfollow stops following the feed with the form's url.
+func (wb *web) handleUnfollow(w http.ResponseWriter, r *http.Request, user database.User) error {
+	feedURL := r.FormValue("url")
+	deleted, err := wb.s.db.DeleteFeedFollow(r.Context(), database.DeleteFeedFollowParams{
+		Url:  feedURL,
+		Name: user.Name,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return newError(errNotFound, "you don't follow %s", feedURL)
+	}
+	http.Redirect(w, r, "/follows", http.StatusSeeOther)
+	return nil
+}
+
+// renderFollows shows the follows page with an optional error.
+func (wb *web) renderFollows(w http.ResponseWriter, r *http.Request, user database.User, status int, message string, page followsPage) error {
+	follows, err := followViews(r.Context(), wb.s, user)
+	if err != nil {
+		return err
+	}
+	page.Follows = follows
+	wb.render(w, status, "follows", pageData{Title: "Follows", User: user.Name, Error: message, Data: page})
+	return nil
+}
+
+// localPath returns path when it stays on this site, so redirects taken
+// from forms and links can't send the browser elsewhere.
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/posts"
+	}
+	return path
+}